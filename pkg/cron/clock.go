@@ -0,0 +1,68 @@
+package cron
+
+import "time"
+
+// Clock is the source of time a Job schedules against, see WithClock.
+// The package uses the real clock unless told otherwise; crontest.FakeClock is one that tests can advance by hand.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a pending wake-up handed out by a Clock, behaving like a *time.Timer.
+type Timer interface {
+	// C returns the channel the current time is sent on when the timer fires.
+	C() <-chan time.Time
+	// Stop prevents the timer from firing and reports whether it had not fired yet.
+	Stop() bool
+}
+
+// realClock is the wall clock, backed by the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+// realTimer adapts a *time.Timer to Timer.
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+// stopTimer stops t and, if it fired in the meantime, drains its channel, so a timer abandoned on the way
+// out of a select holds on to nothing. A nil timer, standing in for one never armed, is ignored.
+func stopTimer(t Timer) {
+	if t == nil {
+		return
+	}
+	if !t.Stop() {
+		select {
+		case <-t.C():
+		default:
+		}
+	}
+}
+
+// WithClock makes the Job read the time and wait for its fire times through c instead of the real clock,
+// so tests can control time. It covers the scheduling loop, run timestamps and durations, and the backoff
+// between retries; WithTimeout deadlines still follow the real clock. Jobs driven by a Manager or Scheduler
+// wait on the real clock, so WithClock is meant for jobs started on their own. Passing nil restores the real clock.
+func (j *Job) WithClock(c Clock) *Job {
+	if c == nil {
+		c = realClock{}
+	}
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.clock = c
+	return j
+}
+
+// clockNow returns the current time according to the Job's Clock.
+func (j *Job) clockNow() time.Time {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.clock.Now()
+}
@@ -2,22 +2,78 @@
 // It allows users to schedule functions to be executed at specific times or intervals,
 // following a format similar to traditional UNIX cron, but with extended support for seconds and milliseconds.
 // Parsing of UNIX cron is done by https://pkg.go.dev/github.com/robfig/cron/v3@v3.0.1
+//
+// Schedules take 5 fields (minute to day of week), 6 fields (with a leading seconds field)
+// or 7 fields (with leading milliseconds and seconds fields), for example "*/250 * * * * * *"
+// fires four times a second. Months and weekdays can also be given by their three-letter names in any case,
+// as in "0 9 * * MON-FRI" or "0 0 1 JAN *". Go timers are only as precise as the runtime's scheduler,
+// which typically wakes a millisecond or two late and more under load,
+// so intervals below about 10ms should not be relied upon.
+//
+// Across daylight saving transitions, schedules with fixed hours follow the wall clock: a time that is
+// skipped when clocks spring forward fires right after the jump (02:30 becomes 03:30), and a time that is
+// repeated when clocks fall back fires once, on its first occurrence. Schedules that fire every hour
+// keep firing once per elapsed hour. Timers always target absolute instants, so neither case drifts.
 package cron
 
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"runtime/debug"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	_cron "github.com/robfig/cron/v3"
 )
 
+// LimitPolicy controls what a Job does when a tick fires while a concurrency limit it is subject to is saturated.
+type LimitPolicy int
+
+const (
+	// LimitQueue waits for a free slot, giving up only if the job's context is cancelled. This is the default.
+	// A Scheduler's pool doesn't wait on behalf of a shared timer, see Scheduler.WithMaxConcurrency.
+	LimitQueue LimitPolicy = iota
+	// LimitSkip drops the tick when no slot is free.
+	LimitSkip
+)
+
+// finalRunTimeout bounds the last execution made by Stop for jobs configured with RunOnStop.
+const finalRunTimeout = 30 * time.Second
+
+// Reasons passed to the OnSkip callback when a tick does not run the job's function.
+const (
+	// SkipWaitingOnDeps means a dependency registered with DependsOn has not run since the job last ran.
+	SkipWaitingOnDeps = "waiting-on-deps"
+	// SkipPredicate means the predicate registered with SkipWhen returned true.
+	SkipPredicate = "skip-predicate"
+	// SkipNoFunc means the job's function was cleared while the job was running.
+	SkipNoFunc = "no-func"
+	// SkipPaused means the job was paused with Pause.
+	SkipPaused = "paused"
+	// SkipRunning means SkipIfRunning is enabled and the previous run had not returned yet.
+	SkipRunning = "already-running"
+)
+
+// defaultTimezone is the timezone given to new jobs, UTC when nil, see SetDefaultTimezone.
+var defaultTimezone atomic.Pointer[time.Location]
+
+// SetDefaultTimezone changes the timezone new jobs are created with, instead of UTC, so a timezone shared
+// by many jobs only needs setting once. It only affects jobs created after the call, so startup code should
+// call it before building any job. It is safe for concurrent use. Passing nil restores UTC.
+func SetDefaultTimezone(loc *time.Location) {
+	defaultTimezone.Store(loc)
+}
+
 // Job represents a cron job with a specific schedule and task.
 // It holds the schedule string, the parsed schedule, execution settings like blocking behavior, timezone,
 // and the function to execute.
 type Job struct {
+	Name        string `json:"name"`
 	scheduleStr string
 	Schedule    _cron.Schedule  `json:"schedule"`
 	Blocking    bool            `json:"blocking"`
@@ -27,54 +83,628 @@ type Job struct {
 	Fn          func(ctx context.Context) `json:"-"`
 	isRunning   bool
 	mutex       sync.RWMutex
+
+	// parent is the context Ctx was derived from; its values and cancellation flow into Ctx
+	parent context.Context
+	// observer is notified after every execution, set when the job is added to a Manager
+	observer func(fireTime time.Time, duration time.Duration, err error)
+	// edgeCond gates each tick on a false to true transition, edgeState is its last observed value
+	edgeCond  func(ctx context.Context) bool
+	edgeState bool
+	// limiter is a semaphore shared with other jobs, limitPolicy decides how to acquire it
+	limiter     chan struct{}
+	limitPolicy LimitPolicy
+	// runOnceIfDone makes StartE run the function once when the context is already done
+	runOnceIfDone bool
+	// deps must each have run since this job last did, see DependsOn
+	deps    []*Job
+	onSkip  func(reason string)
+	lastRun time.Time
+	// armedAt is the fire time the scheduling loop is currently waiting for
+	armedAt time.Time
+	// onCancel is called when a run's context is cancelled before the run returns
+	onCancel func()
+	// runtimeBudget caps totalRuntime, the time spent across all runs; stopCause says why the Job stopped itself
+	runtimeBudget time.Duration
+	totalRuntime  time.Duration
+	stopCause     error
+	// data is arbitrary user state, see SetData
+	data interface{}
+	// values are attached to every run's context, see WithContextValue
+	values []contextValue
+	// skipWhen vetoes a tick when it returns true
+	skipWhen func() bool
+	// skipDates and skipFunc exclude fire times from the schedule, see Skip and SkipFunc
+	skipDates map[date]bool
+	skipFunc  func(t time.Time) bool
+	// runCount is the number of executions, inflight tracks the ones still in progress
+	runCount atomic.Uint64
+	inflight inflight
+	// everyNth fires only every nth occurrence, occurrences counts them since Start
+	everyNth    int
+	occurrences int
+	// skipFirst passes over the first fire time after each Start, skipPending tracks whether that is still due
+	skipFirst   bool
+	skipPending bool
+	// runOnStop makes Stop run the function one last time
+	runOnStop bool
+	// fixedDelay measures the schedule from the end of each run, see AfterEach
+	fixedDelay bool
+	// onDrop is told about ticks discarded because of a saturated concurrency limit
+	onDrop func(scheduled time.Time)
+	// pool runs non-blocking executions for a Scheduler with a concurrency limit, see Scheduler.WithMaxConcurrency
+	pool *workerPool
+	// paused lets ticks pass without running the function, see Pause
+	paused bool
+	// task is the error-returning function set by ExecuteE, onError receives its errors
+	task    func(ctx context.Context) error
+	onError func(err error)
+	// more are run after the function at every tick, see AddFunc
+	more []func(ctx context.Context)
+	// onPanic is told about panics recovered from the function
+	onPanic func(recovered interface{}, stack []byte)
+	// maxAttempts bounds how often a failing run is tried, waiting retryBackoff (grown by retryMultiplier) in between
+	maxAttempts     int
+	retryBackoff    time.Duration
+	retryMultiplier float64
+	// runTimeout bounds each run, see WithTimeout
+	runTimeout time.Duration
+	// skipIfRunning drops ticks while active, the flag set for the duration of each scheduled run;
+	// skipped counts the ticks dropped that way
+	skipIfRunning bool
+	active        atomic.Bool
+	skipped       atomic.Uint64
+	// delayIfRunning makes each run wait for its turn on serial, a semaphore with a single slot
+	delayIfRunning bool
+	serial         chan struct{}
+	// maxRuns stops the Job once runCount reaches it, see MaxRuns
+	maxRuns int
+	// startTime holds back fire times until it has passed, see After
+	startTime time.Time
+	// endTime stops the Job instead of arming a fire time at or after it, see Until
+	endTime time.Time
+	// runOnStart runs the function as soon as the Job starts, before its first scheduled time
+	runOnStart bool
+	// firstRunAt is when the Job first fires after Start, which begin settles: either at once, as runFirst
+	// tells the scheduling loop, or at the time in firstArm, which the loop's first arm takes up
+	firstRunAt time.Time
+	runFirst   bool
+	firstArm   *armed
+	// alignFirst counts intervals from the clock rather than from Start, see AlignFirstRun
+	alignFirst bool
+	// catchUp does the same if a fire time was missed since lastRun, see CatchUp
+	catchUp bool
+	// jitter is the most each fire time is randomly delayed by, drawn from rng
+	jitter time.Duration
+	rng    *rand.Rand
+	// onStart and onStop bracket each run of the scheduling loop, ended makes sure onStop is called once per start
+	// and halted is closed along with it
+	onStart func()
+	onStop  func()
+	ended   *once
+	halted  chan struct{}
+	// onBeforeRun and onAfterRun wrap every call of the function
+	onBeforeRun func(ctx context.Context)
+	onAfterRun  func(ctx context.Context, duration time.Duration)
+	// logger receives diagnostics, see WithLogger
+	logger Logger
+	// metrics is told about runs and skips, see WithMetrics
+	metrics Metrics
+	// tracer wraps each run in a span, see WithTracer
+	tracer Tracer
+	// dryRun logs runs instead of calling the function, dryRuns counts them, see DryRun
+	dryRun  bool
+	dryRuns atomic.Uint64
+	// clock tells the time and makes timers, see WithClock
+	clock Clock
+	// ran is closed, and cleared, when the next run returns; it is only made while someone waits, see WaitForNextRun
+	ran chan struct{}
+	// exited is closed once the scheduling loop of the latest StartE returns; it is nil for jobs driven by an engine
+	exited chan struct{}
+	// retired holds the done channels of contexts WithContext replaced since Start, see retarget
+	retired map[<-chan struct{}]bool
+	// wake makes the scheduling loop recompute its next fire time, rearm does the same
+	// for a Job driven by a Manager; followers are woken whenever this Job runs
+	wake      chan struct{}
+	rearm     func()
+	followers []*Job
+}
+
+// JobState is the runtime state of a Job worth persisting across restarts.
+type JobState struct {
+	LastRun  time.Time `json:"last_run"`
+	RunCount uint64    `json:"run_count"`
+}
+
+// JobConfig is a consistent, read-only snapshot of a Job's configuration, see Config.
+// It holds the settings made by the Job's options, but not its callbacks, hooks and functions,
+// nor its Skip dates, dependencies and attached data.
+type JobConfig struct {
+	Name                 string        `json:"name,omitempty"`
+	ScheduleString       string        `json:"schedule_str"`
+	Blocking             bool          `json:"blocking"`
+	TimezoneName         string        `json:"timezone"`
+	LimitPolicy          LimitPolicy   `json:"limit_policy"`
+	EveryNth             int           `json:"every_nth,omitempty"`
+	SkipFirst            bool          `json:"skip_first,omitempty"`
+	RunOnceIfDone        bool          `json:"run_once_if_done,omitempty"`
+	MaxCumulativeRuntime time.Duration `json:"max_cumulative_runtime,omitempty"`
+	SkipIfRunning        bool          `json:"skip_if_running,omitempty"`
+	DelayIfRunning       bool          `json:"delay_if_running,omitempty"`
+	MaxRuns              int           `json:"max_runs,omitempty"`
+	Timeout              time.Duration `json:"timeout,omitempty"`
+	Jitter               time.Duration `json:"jitter,omitempty"`
+	MaxAttempts          int           `json:"max_attempts,omitempty"`
+	RetryBackoff         time.Duration `json:"retry_backoff,omitempty"`
+	BackoffMultiplier    float64       `json:"backoff_multiplier,omitempty"`
+	// After and Until are zero unless set
+	After         time.Time `json:"after"`
+	Until         time.Time `json:"until"`
+	RunOnStart    bool      `json:"run_on_start,omitempty"`
+	RunOnStop     bool      `json:"run_on_stop,omitempty"`
+	CatchUp       bool      `json:"catch_up,omitempty"`
+	AlignFirstRun bool      `json:"align_first_run,omitempty"`
+	DryRun        bool      `json:"dry_run,omitempty"`
+}
+
+// inflight counts executions in progress and lets callers wait for them to finish.
+type inflight struct {
+	mutex sync.Mutex
+	count int
+	// idle is closed whenever count drops to zero
+	idle chan struct{}
+}
+
+func (f *inflight) add() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.count == 0 {
+		f.idle = make(chan struct{})
+	}
+	f.count++
+}
+
+func (f *inflight) done() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.count--
+	if f.count == 0 {
+		close(f.idle)
+	}
+}
+
+// wait blocks until no executions are in progress or ctx is done.
+func (f *inflight) wait(ctx context.Context) error {
+	f.mutex.Lock()
+	idle := f.idle
+	f.mutex.Unlock()
+	if idle == nil {
+		return nil
+	}
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		// executions that finished just in time count as finished
+		select {
+		case <-idle:
+			return nil
+		default:
+			return ctx.Err()
+		}
+	}
+}
+
+// Kinds of schedule, as written to the "kind" field of a Job's JSON.
+const (
+	kindCron       = "cron"
+	kindDescriptor = "descriptor"
+	kindInterval   = "interval"
+	kindAfterEach  = "after-each"
+	kindAfterJob   = "after-job"
+	kindUnion      = "union"
+)
+
+// scheduleKind tells which kind of schedule a schedule string describes.
+func scheduleKind(scheduleStr string) string {
+	switch {
+	case strings.Contains(scheduleStr, ";"):
+		return kindUnion
+	case strings.HasPrefix(scheduleStr, "@after-each "):
+		return kindAfterEach
+	case strings.HasPrefix(scheduleStr, "@after-job "):
+		return kindAfterJob
+	case strings.HasPrefix(scheduleStr, "@every "):
+		return kindInterval
+	case strings.HasPrefix(scheduleStr, "@"):
+		return kindDescriptor
+	}
+	return kindCron
+}
+
+// normalizeSchedule separates the fields of a schedule string by single spaces,
+// and the schedules of a union by a semicolon and a space.
+func normalizeSchedule(scheduleStr string) string {
+	parts := strings.Split(scheduleStr, ";")
+	for i, part := range parts {
+		parts[i] = strings.Join(strings.Fields(part), " ")
+	}
+	return strings.Join(parts, "; ")
 }
 
 // MarshalJSON customizes the JSON output of Job.
+// The schedule is written with its fields separated by single spaces along with its kind: "cron",
+// "descriptor" such as "@daily", "interval" for "@every 30s", "after-each", "after-job", or "union" for
+// several schedules built with Schedules.
+// The timezone is written as its IANA name so the output can be read back by UnmarshalJSON.
 func (j *Job) MarshalJSON() ([]byte, error) {
 	type Alias Job
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	scheduleStr := normalizeSchedule(j.scheduleStr)
 	return json.Marshal(&struct {
 		ScheduleStr string `json:"schedule_str"`
+		Kind        string `json:"kind"`
+		Timezone    string `json:"timezone"`
 		*Alias
 	}{
-		ScheduleStr: j.scheduleStr,
+		ScheduleStr: scheduleStr,
+		Kind:        scheduleKind(scheduleStr),
+		Timezone:    j.Timezone.String(),
 		Alias:       (*Alias)(j),
 	})
 }
 
+// UnmarshalJSON reconstructs a Job from the output of MarshalJSON by re-parsing its schedule string
+// according to its kind, and restoring its name, blocking setting and timezone. Functions can't be serialized,
+// so Fn is left nil, and the Job gets a fresh background context. A missing kind is inferred from the schedule
+// string. Jobs scheduled with AfterJob can't be restored since they refer to another Job.
+// It should not be used on a running Job.
+func (j *Job) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Name        string `json:"name"`
+		ScheduleStr string `json:"schedule_str"`
+		Kind        string `json:"kind"`
+		Blocking    bool   `json:"blocking"`
+		Timezone    string `json:"timezone"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Kind != "" && raw.Kind != scheduleKind(raw.ScheduleStr) {
+		return fmt.Errorf("cron: schedule %q is not of kind %q", raw.ScheduleStr, raw.Kind)
+	}
+	parsed, err := restoreSchedule(raw.ScheduleStr)
+	if err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(raw.Timezone)
+	if err != nil {
+		return fmt.Errorf("cron: invalid timezone %q: %w", raw.Timezone, err)
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.Name = raw.Name
+	j.scheduleStr = parsed.scheduleStr
+	j.Schedule = parsed.Schedule
+	j.fixedDelay = parsed.fixedDelay
+	j.Blocking = raw.Blocking
+	j.Timezone = loc
+	j.Fn, j.task, j.more = nil, nil, nil
+	j.Ctx, j.cancelFunc, j.parent = parsed.Ctx, parsed.cancelFunc, parsed.parent
+	j.wake, j.serial = parsed.wake, parsed.serial
+	if j.clock == nil {
+		j.clock = parsed.clock
+	}
+	return nil
+}
+
+// restoreSchedule builds a Job from a schedule string written out by MarshalJSON or Manager.ExportJSON,
+// according to its kind, so jobs built with AfterEach come back as such.
+func restoreSchedule(scheduleStr string) (*Job, error) {
+	switch scheduleKind(scheduleStr) {
+	case kindAfterJob:
+		return nil, fmt.Errorf("cron: schedule %q follows another job and can't be restored", scheduleStr)
+	case kindAfterEach:
+		// AfterEach jobs are fixed-delay, which a parsed schedule alone can't express
+		delay, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(scheduleStr, "@after-each ")))
+		if err != nil || delay <= 0 {
+			return nil, &ScheduleError{Schedule: scheduleStr, Err: errors.New("@after-each takes a positive duration")}
+		}
+		return AfterEach(delay), nil
+	}
+	return ScheduleE(scheduleStr)
+}
+
 // Schedule initializes a new Job with a given cron schedule string.
 // The function panics if the schedule string is invalid.
-// The schedule string supports the traditional UNIX cron format with optional seconds field at the beginning.
+// The schedule string supports the traditional UNIX cron format with optional seconds field at the beginning,
+// optionally preceded by a milliseconds field, as well as descriptors such as "@daily" and "@every 90s".
+// Several schedules separated by semicolons fire on all of their times, see Schedules.
+// Use ScheduleE when the string comes from user input or configuration.
 func Schedule(scheduleStr string) *Job {
+	j, err := ScheduleE(scheduleStr)
+	if err != nil {
+		panic(err)
+	}
+	return j
+}
+
+// ScheduleE is like Schedule but returns an error instead of panicking when the schedule string is invalid.
+// The error is a *ScheduleError matching ErrInvalidSchedule. It quotes the string, names the field
+// that failed and its valid range, and wraps the underlying parser error. A string with the wrong
+// number of fields is reported with ErrFieldCount instead, and an empty or whitespace-only string as such.
+func ScheduleE(scheduleStr string) (*Job, error) {
+	schedule, err := parseSchedule(scheduleStr)
+	if err != nil {
+		return nil, err
+	}
+	return newJob(scheduleStr, schedule), nil
+}
+
+// Schedules initializes a new Job firing on the union of several schedules, for times no single expression
+// captures such as "0 8 * * *" and "30 20 * * 5". The schedules are parsed as by Schedule and joined with
+// semicolons into the Job's schedule string, so Schedule("0 8 * * *; 30 20 * * 5") builds the same Job.
+// Start and Stop control them all together, NextRun returns the earliest of their next times,
+// and times shared by several schedules fire once. The function panics if any schedule is invalid.
+func Schedules(scheduleStrs ...string) *Job {
+	j, err := SchedulesE(scheduleStrs...)
+	if err != nil {
+		panic(err)
+	}
+	return j
+}
+
+// SchedulesE is like Schedules but returns an error instead of panicking when a schedule string is invalid.
+// The error is a *ScheduleError whose reason quotes the offending schedule.
+func SchedulesE(scheduleStrs ...string) (*Job, error) {
+	return ScheduleE(strings.Join(scheduleStrs, "; "))
+}
+
+// ScheduleStrict is like ScheduleE but only accepts standard 5-field crontab syntax, along with descriptors
+// such as "@daily" other than "@every". Expressions with a seconds or milliseconds field are rejected with
+// ErrFieldCount, so configuration can't accidentally ask for a job that fires every second.
+func ScheduleStrict(scheduleStr string) (*Job, error) {
+	for _, fields := range scheduleParts(scheduleStr) {
+		if len(fields) > 0 && fields[0] == "@every" {
+			return nil, &ScheduleError{Schedule: scheduleStr, Err: errors.New("@every is not standard crontab syntax")}
+		}
+	}
+	return scheduleFields(scheduleStr, 5)
+}
+
+// ScheduleWithSeconds is like ScheduleE but requires the 6-field form with a leading seconds field,
+// rejecting anything else with ErrFieldCount.
+func ScheduleWithSeconds(scheduleStr string) (*Job, error) {
+	return scheduleFields(scheduleStr, 6)
+}
+
+// scheduleFields is ScheduleE for expressions that must have exactly n fields, each of them for a union.
+// Descriptors are only let through when n is 5.
+func scheduleFields(scheduleStr string, n int) (*Job, error) {
+	parts := scheduleParts(scheduleStr)
+	for _, fields := range parts {
+		descriptor := len(fields) > 0 && strings.HasPrefix(fields[0], "@")
+		if len(fields) == 0 || len(fields) == n || (descriptor && n == 5) {
+			continue
+		}
+		err := fmt.Errorf("%w: expected %d fields, found %d", ErrFieldCount, n, len(fields))
+		if len(parts) > 1 {
+			// quoted like parseUnion quotes the schedule it failed on
+			err = fmt.Errorf("%q: %w", strings.Join(fields, " "), err)
+		}
+		return nil, &ScheduleError{Schedule: scheduleStr, Err: err}
+	}
+	return ScheduleE(scheduleStr)
+}
+
+// scheduleParts splits a schedule string into the fields of each schedule separated by semicolons,
+// leaving out any leading "TZ=" or "CRON_TZ=" timezone.
+func scheduleParts(scheduleStr string) [][]string {
+	var parts [][]string
+	for _, part := range strings.Split(scheduleStr, ";") {
+		fields := strings.Fields(part)
+		if len(fields) > 0 && isZone(fields[0]) {
+			fields = fields[1:]
+		}
+		parts = append(parts, fields)
+	}
+	return parts
+}
+
+// isZone reports whether field sets the timezone of an expression, as in "CRON_TZ=America/New_York 0 9 * * *".
+func isZone(field string) bool {
+	return strings.HasPrefix(field, "TZ=") || strings.HasPrefix(field, "CRON_TZ=")
+}
+
+// Validate checks a schedule string the same way Schedule parses it, without building a Job.
+// It returns the error ScheduleE would, or nil if the string is valid.
+func Validate(scheduleStr string) error {
+	_, err := parseSchedule(scheduleStr)
+	return err
+}
+
+// parseSchedule is parse with the error wrapped in a *ScheduleError, the way ScheduleE reports it.
+func parseSchedule(scheduleStr string) (_cron.Schedule, error) {
+	schedule, err := parse(scheduleStr)
+	if err != nil {
+		return nil, &ScheduleError{Schedule: scheduleStr, Err: err}
+	}
+	return schedule, nil
+}
+
+// parser handles 5- and 6-field expressions and descriptors in a single pass:
+// a leading seconds field is optional and defaults to zero.
+var parser = _cron.NewParser(_cron.SecondOptional | _cron.Minute | _cron.Hour | _cron.Dom | _cron.Month | _cron.Dow | _cron.Descriptor)
+
+// parse parses a cron schedule string. Everything goes through parser except the 7-field
+// milliseconds form and "@every", which robfig would round to whole seconds, and lists of
+// schedules separated by semicolons, see Schedules.
+func parse(scheduleStr string) (_cron.Schedule, error) {
+	if strings.Contains(scheduleStr, ";") {
+		return parseUnion(scheduleStr)
+	}
+	fields := strings.Fields(scheduleStr)
+	if len(fields) == 0 {
+		return nil, errors.New("empty schedule")
+	}
+	if fields[0] == "@every" {
+		return parseEvery(fields)
+	}
+	// a leading timezone is left to robfig but isn't a field of the expression
+	var zone []string
+	if isZone(fields[0]) {
+		zone, fields = fields[:1:1], fields[1:]
+	}
+	if len(fields) == 7 {
+		return parseMillisecond(zone, fields)
+	}
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "@") && (len(fields) < 5 || len(fields) > 7) {
+		return nil, fmt.Errorf("%w: expected 5, 6 or 7 fields, found %d", ErrFieldCount, len(fields))
+	}
+	schedule, err := parser.Parse(strings.Join(append(zone, fields...), " "))
+	if err != nil {
+		return nil, fieldError(strings.Join(fields, " "), err)
+	}
+	return withWallClock(schedule), nil
+}
+
+// parseEvery parses "@every <duration>" into the same schedule Every builds, so it keeps sub-second precision.
+func parseEvery(fields []string) (_cron.Schedule, error) {
+	if len(fields) != 2 {
+		return nil, errors.New("@every takes a single duration")
+	}
+	d, err := time.ParseDuration(fields[1])
+	if err != nil {
+		return nil, err
+	}
+	if d <= 0 {
+		return nil, fmt.Errorf("@every interval %v must be positive", d)
+	}
+	return intervalSchedule(d), nil
+}
+
+// cronFields names the fields of a 6-field expression along with their valid ranges.
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"seconds", 0, 59},
+	{"minutes", 0, 59},
+	{"hours", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// fieldError pinpoints which field of an invalid expression is to blame for err,
+// by parsing each field on its own with every other field set to "*".
+// err is returned unchanged when no single field fails, for example on a wrong field count.
+func fieldError(scheduleStr string, err error) error {
 	fields := strings.Fields(scheduleStr)
-	var parser _cron.Parser
+	names := cronFields
+	switch len(fields) {
+	case 6:
+	case 5:
+		names = cronFields[1:]
+	default:
+		return err
+	}
 
-	if len(fields) == 6 {
-		parser = _cron.NewParser(_cron.Second | _cron.Minute | _cron.Hour | _cron.Dom | _cron.Month | _cron.Dow)
-	} else {
-		parser = _cron.NewParser(_cron.Minute | _cron.Hour | _cron.Dom | _cron.Month | _cron.Dow)
+	for i, field := range fields {
+		probe := make([]string, len(fields))
+		for k := range probe {
+			probe[k] = "*"
+		}
+		probe[i] = field
+		if _, fieldErr := parser.Parse(strings.Join(probe, " ")); fieldErr != nil {
+			f := names[i]
+			return fmt.Errorf("%s field %q (valid range %d-%d): %w", f.name, field, f.min, f.max, fieldErr)
+		}
 	}
+	return err
+}
 
-	schedule, err := parser.Parse(scheduleStr)
+// Every initializes a new Job that fires every d, measured from Start rather than aligned to the wall clock,
+// for intervals such as 90 seconds that cron expressions can't express.
+// The function panics if d is not positive. Use EveryE to get an error instead.
+func Every(d time.Duration) *Job {
+	j, err := EveryE(d)
 	if err != nil {
-		panic("invalid cron schedule")
+		panic(err)
+	}
+	return j
+}
+
+// EveryE is like Every but returns an error instead of panicking when d is not positive.
+func EveryE(d time.Duration) (*Job, error) {
+	if d <= 0 {
+		return nil, &ScheduleError{Schedule: "@every " + d.String(), Err: errors.New("interval must be positive")}
+	}
+	return newJob("@every "+d.String(), intervalSchedule(d)), nil
+}
+
+// AfterEach initializes a new Job with fixed-delay scheduling: each run starts d after the previous one finished,
+// rather than on a fixed wall-clock cadence like cron expressions do. The first run happens d after Start.
+// Runs never overlap, so the Job always behaves as if SetBlocking(true) was called.
+// The function panics if d is not positive.
+func AfterEach(d time.Duration) *Job {
+	if d <= 0 {
+		panic("invalid delay")
 	}
+	j := newJob("@after-each "+d.String(), intervalSchedule(d))
+	j.fixedDelay = true
+	return j
+}
+
+// newJob builds a Job with the package defaults around an already parsed schedule.
+func newJob(scheduleStr string, schedule _cron.Schedule) *Job {
 	// Default context
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	parent := context.Background()
+	ctx, cancelFunc := context.WithCancel(parent)
+	loc := defaultTimezone.Load()
+	if loc == nil {
+		loc = time.UTC
+	}
 	return &Job{
 		scheduleStr: scheduleStr,
 		Schedule:    schedule,
 		// Default non-blocking
 		Blocking: false,
-		// Default to UTC
-		Timezone:   time.UTC,
+		// Default to UTC unless SetDefaultTimezone says otherwise
+		Timezone:   loc,
 		Ctx:        ctx,
+		parent:     parent,
 		cancelFunc: cancelFunc,
+		clock:      realClock{},
+		wake:       make(chan struct{}, 1),
+		serial:     make(chan struct{}, 1),
 	}
 }
 
 // now returns the current time in the Job's timezone.
 func (j *Job) now() time.Time {
-	return time.Now().In(j.Timezone)
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.clock.Now().In(j.Timezone)
+}
+
+// SetName gives the Job a descriptive name, shown in its JSON and passed along in logs.
+// Names don't need to be unique; a Job added to a Manager without one takes the name it is registered under.
+func (j *Job) SetName(name string) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.Name = name
+	return j
+}
+
+// name returns the Job's Name.
+func (j *Job) name() string {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.Name
 }
 
 // SetBlocking configures the Job's blocking behavior.
@@ -89,18 +719,70 @@ func (j *Job) SetBlocking(blocking bool) *Job {
 
 // WithContext sets a custom context for the Job.
 // This context is used for controlling the execution of the job's function.
+// The Job derives its own cancellable context from ctx, so any values carried by ctx
+// are visible to the job's function while Stop still only cancels the Job.
+// Calling it on a running Job swaps the context in place: the previous one is cancelled, which
+// cancels any run in progress, and the Job keeps its schedule under the new one until it is
+// stopped or ctx is done.
 func (j *Job) WithContext(ctx context.Context) *Job {
 	// locking in case you change on the fly but would not recommend
 	j.mutex.Lock()
 	defer j.mutex.Unlock()
+	if j.isRunning {
+		// the loop notices the old context is done and moves over, see retarget
+		if j.retired == nil {
+			j.retired = make(map[<-chan struct{}]bool)
+		}
+		j.retired[j.Ctx.Done()] = true
+	}
 	// cancel the previous context
 	if j.cancelFunc != nil {
 		j.cancelFunc()
 	}
+	j.parent = ctx
 	j.Ctx, j.cancelFunc = context.WithCancel(ctx)
 	return j
 }
 
+// Reset replaces the Job's schedule with a new schedule string, parsed as by Schedule, for example after
+// a user edits it. A running Job keeps its scheduling goroutine: it drops the fire time it was waiting
+// for and arms the next one from the new schedule straight away, or once a blocking run in progress returns.
+// An invalid string is reported as ScheduleE would report it and leaves the old schedule in place.
+// A Job built with AfterEach or AfterJob becomes an ordinary one.
+func (j *Job) Reset(scheduleStr string) error {
+	schedule, err := parseSchedule(scheduleStr)
+	if err != nil {
+		return err
+	}
+	j.mutex.Lock()
+	j.scheduleStr = scheduleStr
+	j.Schedule = schedule
+	j.fixedDelay = false
+	j.mutex.Unlock()
+	j.notify()
+	return nil
+}
+
+// contextValue is a value WithContextValue attaches to every run's context.
+type contextValue struct {
+	key, val interface{}
+}
+
+// WithContextValue attaches a value, such as a tenant ID or a logger, to the context of every run, so the function
+// can read it with ctx.Value(key) without a whole context being built and passed to WithContext. Calls stack, with
+// later values shadowing earlier ones under the same key. The values are layered on each run's context rather than
+// on the Job's, so they survive WithContext and adding one doesn't disturb a running Job.
+func (j *Job) WithContextValue(key, val interface{}) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	// copied so a run in progress keeps reading the previous values
+	values := make([]contextValue, len(j.values), len(j.values)+1)
+	copy(values, j.values)
+	j.values = append(values, contextValue{key, val})
+	return j
+}
+
 // SetTimezone sets the timezone in which the Job's schedule will be interpreted.
 func (j *Job) SetTimezone(loc *time.Location) *Job {
 	// locking in case you change on the fly but would not recommend
@@ -110,60 +792,1795 @@ func (j *Job) SetTimezone(loc *time.Location) *Job {
 	return j
 }
 
-// Execute sets the function (Fn) to be executed by the Job.
+// SetTimezoneE is like SetTimezone but first checks that the location is usable,
+// returning an error instead of letting a bad location panic later inside the scheduling loop.
+func (j *Job) SetTimezoneE(loc *time.Location) (*Job, error) {
+	if err := checkLocation(loc); err != nil {
+		return j, err
+	}
+	return j.SetTimezone(loc), nil
+}
+
+// SetTimezoneName is like SetTimezone but takes an IANA timezone name such as "America/New_York",
+// as stored in configuration. The timezone is left unchanged if the name can't be loaded.
+func (j *Job) SetTimezoneName(name string) (*Job, error) {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return j, fmt.Errorf("cron: invalid timezone %q: %w", name, err)
+	}
+	return j.SetTimezone(loc), nil
+}
+
+// checkLocation reports whether times can be converted into loc.
+func checkLocation(loc *time.Location) (err error) {
+	if loc == nil {
+		return errors.New("cron: nil timezone")
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cron: unusable timezone %q: %v", loc, r)
+		}
+	}()
+	time.Now().In(loc)
+	return nil
+}
+
+// Execute sets the function (Fn) to be executed by the Job, replacing any added with AddFunc.
 // The provided function should accept a context.Context parameter.
+// Passing nil is a no-op that keeps the current function, and is logged as a mistake to the Logger set with WithLogger.
 func (j *Job) Execute(fn func(ctx context.Context)) *Job {
+	if fn == nil {
+		j.warnNilFunc("Execute")
+		return j
+	}
 	// locking in case you change on the fly but would not recommend
 	j.mutex.Lock()
 	defer j.mutex.Unlock()
 	j.Fn = fn
+	j.task = nil
+	j.more = nil
 	return j
 }
 
-// Start initiates the execution of the Job according to its schedule.
-// The job runs either synchronously or asynchronously based on its Blocking setting.
-func (j *Job) Start() {
+// warnNilFunc logs that method was given a nil function, which it ignored.
+func (j *Job) warnNilFunc(method string) {
+	j.mutex.RLock()
+	logger, name := j.log(), j.Name
+	j.mutex.RUnlock()
+	logger.Error("cron: nil function ignored, keeping the current one", "job", name, "method", method)
+}
+
+// AddFunc adds a function run at every tick after the Job's function and those added before it, in the order
+// they were added, for composing small independent steps without wrapping them in one closure. Without
+// a function yet, fn becomes Fn. The functions make up a single run, one after another on the same goroutine
+// whether or not the Job is blocking: they share its context, timeout and retries, a function returning
+// doesn't stop the next one, and an error from a function set with ExecuteE is reported once they are
+// all done. A panic ends the run. Execute and ExecuteE go back to a single function.
+// Passing nil is a no-op.
+func (j *Job) AddFunc(fn func(ctx context.Context)) *Job {
+	if fn == nil {
+		return j
+	}
+	// locking in case you change on the fly but would not recommend
 	j.mutex.Lock()
-	if j.Fn == nil || j.isRunning {
-		j.mutex.Unlock()
-		return
+	defer j.mutex.Unlock()
+	if j.Fn == nil {
+		j.Fn = fn
+		j.task = nil
+		return j
 	}
-	j.isRunning = true
-	j.mutex.Unlock()
+	// copied so a run in progress keeps reading the previous functions
+	more := make([]func(ctx context.Context), len(j.more), len(j.more)+1)
+	copy(more, j.more)
+	j.more = append(more, fn)
+	return j
+}
 
-	done := j.Ctx.Done()
-	go func() {
-		for {
-			j.mutex.RLock()
-			previousRun := j.now()
-			// Schedule has a next function that tells you when to run the job next
-			// https://pkg.go.dev/github.com/robfig/cron#Schedule
-			currentRun := j.Schedule.Next(previousRun)
-			timer := time.NewTimer(currentRun.Sub(j.now()))
-			isBlocking := j.Blocking
-			j.mutex.RUnlock()
-			select {
-			case <-timer.C:
-				if isBlocking {
-					j.Fn(j.Ctx)
-				} else {
-					go j.Fn(j.Ctx)
-				}
-			case <-done:
-				timer.Stop()
-				return
-			}
-		}
-	}()
+// ExecuteE is like Execute for functions that can fail.
+// Errors they return are passed to the OnError handler, or dropped if there is none.
+// Fn is set to a wrapper that discards the error, so the Job still reports having a function.
+// Passing nil is a no-op that keeps the current function, and is logged like it is for Execute.
+func (j *Job) ExecuteE(fn func(ctx context.Context) error) *Job {
+	if fn == nil {
+		j.warnNilFunc("ExecuteE")
+		return j
+	}
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.Fn = func(ctx context.Context) { _ = fn(ctx) }
+	j.task = fn
+	j.more = nil
+	return j
 }
 
-// Stop halts the execution of the Job.
-// It cancels the Job's context, effectively stopping the running task.
-func (j *Job) Stop() {
+// OnError registers a handler for the errors returned by a function set with ExecuteE.
+// It runs on the goroutine that ran the function: the scheduling goroutine for blocking jobs,
+// the run's own goroutine otherwise. Panics are not passed to it.
+func (j *Job) OnError(fn func(err error)) *Job {
+	// locking in case you change on the fly but would not recommend
 	j.mutex.Lock()
-	if j.isRunning {
-		j.isRunning = false
-		j.cancelFunc()
-	}
-	j.mutex.Unlock()
+	defer j.mutex.Unlock()
+	j.onError = fn
+	return j
+}
+
+// WithEdgeTrigger makes the Job act as an edge detector.
+// On every tick cond is evaluated, and the job's function only runs when cond is true
+// and was false (or not yet evaluated) on the previous tick.
+// cond runs on the scheduling goroutine, so it should return quickly.
+func (j *Job) WithEdgeTrigger(cond func(ctx context.Context) bool) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.edgeCond = cond
+	j.edgeState = false
+	return j
+}
+
+// SetLimitPolicy configures how the Job behaves when a shared concurrency limit,
+// such as the one set by Manager.SetGlobalConcurrency, has no free slot.
+func (j *Job) SetLimitPolicy(policy LimitPolicy) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.limitPolicy = policy
+	return j
+}
+
+// RunOnceIfDone controls what StartE does when the Job's context is already done.
+// By default it returns ErrContextDone without doing anything; when enabled,
+// the function is first run once, synchronously, with the done context.
+func (j *Job) RunOnceIfDone(enabled bool) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.runOnceIfDone = enabled
+	return j
+}
+
+// AfterJob replaces the Job's schedule so it fires delay after other last ran,
+// instead of following a cron expression. Each run of other sets a new target time,
+// which the Job picks up even while it is waiting; if the target has already passed when
+// the Job starts it waits for other's next run.
+func (j *Job) AfterJob(other *Job, delay time.Duration) *Job {
+	other.mutex.Lock()
+	other.followers = append(other.followers, j)
+	other.mutex.Unlock()
+
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.scheduleStr = "@after-job " + delay.String()
+	j.Schedule = afterJobSchedule{other: other, delay: delay}
+	return j
+}
+
+// DependsOn makes the Job wait for other jobs: when its timer fires, it only runs if every
+// dependency has run more recently than the Job itself. Otherwise the tick is skipped with
+// reason SkipWaitingOnDeps. Calling DependsOn again adds to the existing dependencies.
+func (j *Job) DependsOn(deps ...*Job) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.deps = append(j.deps, deps...)
+	return j
+}
+
+// SkipWhen registers a predicate evaluated before every tick; when it returns true the tick
+// is skipped with reason SkipPredicate. It is a general purpose gate, for example to shed non-critical
+// work while the host is under high CPU or memory load. pred runs on the scheduling goroutine,
+// so it should return quickly. Passing nil removes the predicate.
+func (j *Job) SkipWhen(pred func() bool) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.skipWhen = pred
+	return j
+}
+
+// EveryNth decimates the schedule so only every nth occurrence runs the function,
+// starting with the nth one after Start. The other occurrences are counted but skipped
+// silently, without calling OnSkip, so grid alignment is kept: an hourly schedule with
+// EveryNth(3) runs every third hour on the hour. A value of 1 or less runs every occurrence.
+func (j *Job) EveryNth(n int) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.everyNth = n
+	j.occurrences = 0
+	return j
+}
+
+// SkipFirst makes the Job pass over the first scheduled time after Start, so a job started
+// part way through an interval first runs at the end of the following one.
+// It is the inverse of running immediately on start.
+func (j *Job) SkipFirst() *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.skipFirst = true
+	return j
+}
+
+// OnStart registers a callback called every time the Job starts, just before its scheduling loop begins.
+func (j *Job) OnStart(fn func()) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.onStart = fn
+	return j
+}
+
+// OnStop registers a callback called once each time the Job stops, whether through Stop, its context
+// being cancelled, or stopping itself such as after MaxRuns. Calling Stop again doesn't call it again.
+// With RunOnStop, the callback is called before the final run.
+func (j *Job) OnStop(fn func()) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.onStop = fn
+	return j
+}
+
+// OnBeforeRun registers a hook called with the run's context right before every call of the Job's function,
+// on the same goroutine as the function, for instrumentation such as tagging logs.
+func (j *Job) OnBeforeRun(fn func(ctx context.Context)) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.onBeforeRun = fn
+	return j
+}
+
+// OnAfterRun registers a hook called right after every call of the Job's function returns, with how long
+// it took. It runs on the same goroutine as the function, so for non-blocking jobs the duration is the
+// real execution time. Retries from WithRetry count as part of a single call.
+func (j *Job) OnAfterRun(fn func(ctx context.Context, duration time.Duration)) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.onAfterRun = fn
+	return j
+}
+
+// RunOnStart makes Start run the function straight away, for example to warm a cache,
+// and then follow the schedule. The run honors the Blocking setting, receives the Job's context
+// and counts towards RunCount. If the Job is stopped during that run, it doesn't go on to wait for the schedule.
+func (j *Job) RunOnStart(enabled bool) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.runOnStart = enabled
+	return j
+}
+
+// CatchUp makes Start make up for fire times missed while the process was down. When enabled and the schedule
+// had at least one fire time between LastRun, typically loaded with RestoreState, and now, the function runs
+// once straight away, however many fire times were missed, before the Job follows its schedule again.
+// A Job that never ran has nothing to catch up on. It is off by default.
+func (j *Job) CatchUp(enabled bool) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.catchUp = enabled
+	return j
+}
+
+// RunOnStop makes a graceful Stop run the function one final time, for example to flush
+// buffered work. The final run gets a fresh context that is not cancelled by the Stop itself
+// but times out after 30 seconds, and Stop blocks until it returns. Scheduler.Shutdown cancels it
+// sooner if its own context is done first. Jobs that stop themselves, such as after exceeding
+// MaxCumulativeRuntime, don't make the final run.
+func (j *Job) RunOnStop() *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.runOnStop = true
+	return j
+}
+
+// OnDrop sets a callback invoked with the scheduled time of every tick discarded because a shared
+// concurrency limit was saturated: immediately under LimitSkip, or when the job's context is cancelled
+// while waiting for a slot under LimitQueue. This is backpressure, reported separately from OnSkip.
+func (j *Job) OnDrop(fn func(scheduled time.Time)) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.onDrop = fn
+	return j
+}
+
+// OnSkip sets a callback invoked with a reason, such as SkipWaitingOnDeps,
+// whenever a tick fires but the job's function is not run.
+func (j *Job) OnSkip(fn func(reason string)) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.onSkip = fn
+	return j
+}
+
+// OnCancel sets a cleanup callback for runs that are cancelled part way through.
+// It is called, on its own goroutine, when a run's context is cancelled by Stop
+// or by the parent context's cancellation or deadline before the job's function returns.
+// Runs that complete normally never trigger it.
+func (j *Job) OnCancel(fn func()) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.onCancel = fn
+	return j
+}
+
+// MaxCumulativeRuntime stops the Job once the total time spent executing its function,
+// summed across all runs, exceeds d. The run that crosses the limit completes normally,
+// the Job's context is then cancelled and StopCause reports ErrRuntimeBudgetExceeded.
+// A value of zero or less means no limit.
+func (j *Job) MaxCumulativeRuntime(d time.Duration) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.runtimeBudget = d
+	return j
+}
+
+// StopCause returns the reason the Job stopped itself, such as ErrRuntimeBudgetExceeded.
+// It is nil while the Job is running and when it was stopped by Stop or its parent context.
+func (j *Job) StopCause() error {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.stopCause
+}
+
+// SetData attaches an arbitrary value to the Job, such as a service handle,
+// so every callback holding the Job can reach the same state through Data.
+func (j *Job) SetData(v interface{}) *Job {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.data = v
+	return j
+}
+
+// Data returns the value attached with SetData, or nil.
+func (j *Job) Data() interface{} {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.data
+}
+
+// Clone returns a new, stopped Job with the same configuration: its name, schedule, timezone, function,
+// hooks and options. The clone gets its own context derived from the same parent, so stopping one
+// never stops the other, and starts from a clean slate with no runs counted and no Manager.
+// Change its function with Execute or its timezone with SetTimezone to run variations side by side.
+func (j *Job) Clone() *Job {
+	j.mutex.RLock()
+	ctx, cancelFunc := context.WithCancel(j.parent)
+	c := &Job{
+		Name:            j.Name,
+		scheduleStr:     j.scheduleStr,
+		Schedule:        j.Schedule,
+		Blocking:        j.Blocking,
+		Timezone:        j.Timezone,
+		Ctx:             ctx,
+		cancelFunc:      cancelFunc,
+		Fn:              j.Fn,
+		more:            j.more,
+		parent:          j.parent,
+		edgeCond:        j.edgeCond,
+		limitPolicy:     j.limitPolicy,
+		runOnceIfDone:   j.runOnceIfDone,
+		deps:            append([]*Job(nil), j.deps...),
+		onSkip:          j.onSkip,
+		onCancel:        j.onCancel,
+		runtimeBudget:   j.runtimeBudget,
+		data:            j.data,
+		values:          j.values,
+		skipWhen:        j.skipWhen,
+		skipDates:       j.skipDates,
+		skipFunc:        j.skipFunc,
+		everyNth:        j.everyNth,
+		skipFirst:       j.skipFirst,
+		runOnStop:       j.runOnStop,
+		fixedDelay:      j.fixedDelay,
+		onDrop:          j.onDrop,
+		task:            j.task,
+		onError:         j.onError,
+		onPanic:         j.onPanic,
+		maxAttempts:     j.maxAttempts,
+		retryBackoff:    j.retryBackoff,
+		retryMultiplier: j.retryMultiplier,
+		runTimeout:      j.runTimeout,
+		skipIfRunning:   j.skipIfRunning,
+		delayIfRunning:  j.delayIfRunning,
+		maxRuns:         j.maxRuns,
+		startTime:       j.startTime,
+		endTime:         j.endTime,
+		runOnStart:      j.runOnStart,
+		alignFirst:      j.alignFirst,
+		catchUp:         j.catchUp,
+		jitter:          j.jitter,
+		onStart:         j.onStart,
+		onStop:          j.onStop,
+		onBeforeRun:     j.onBeforeRun,
+		onAfterRun:      j.onAfterRun,
+		logger:          j.logger,
+		metrics:         j.metrics,
+		tracer:          j.tracer,
+		dryRun:          j.dryRun,
+		clock:           j.clock,
+		wake:            make(chan struct{}, 1),
+		serial:          make(chan struct{}, 1),
+	}
+	if j.rng != nil {
+		// a rand.Rand can't be shared between jobs, so the clone is seeded afresh
+		c.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	j.mutex.RUnlock()
+
+	if after, ok := c.Schedule.(afterJobSchedule); ok {
+		after.other.mutex.Lock()
+		after.other.followers = append(after.other.followers, c)
+		after.other.mutex.Unlock()
+	}
+	return c
+}
+
+// SameSchedule reports whether other fires on the same schedule as the Job: the same canonical schedule
+// string in the same timezone. Whitespace and the case of month and weekday names don't matter,
+// and intervals compare by duration, so "@every 60s" and "@every 1m" are the same. The functions and
+// runtime state of the jobs are ignored, which lets a config reloader tell whether a job needs restarting.
+func (j *Job) SameSchedule(other *Job) bool {
+	schedule, tz := j.scheduleKey()
+	otherSchedule, otherTz := other.scheduleKey()
+	return schedule == otherSchedule && tz == otherTz
+}
+
+// Equal is like SameSchedule but also compares the jobs' names and blocking settings.
+func (j *Job) Equal(other *Job) bool {
+	if !j.SameSchedule(other) {
+		return false
+	}
+	j.mutex.RLock()
+	name, blocking := j.Name, j.Blocking
+	j.mutex.RUnlock()
+	other.mutex.RLock()
+	defer other.mutex.RUnlock()
+	return name == other.Name && blocking == other.Blocking
+}
+
+// scheduleKey returns the Job's canonical schedule string and the name of its timezone, see SameSchedule.
+func (j *Job) scheduleKey() (string, string) {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	schedule := strings.ToLower(normalizeSchedule(j.scheduleStr))
+	if d, ok := j.Schedule.(intervalSchedule); ok {
+		// spelled out again so equal durations written differently compare equal
+		schedule = strings.Fields(schedule)[0] + " " + time.Duration(d).String()
+	}
+	return schedule, j.Timezone.String()
+}
+
+// Build checks the Job's whole configuration and returns the Job if it can be started, or an error wrapping
+// ErrInvalidConfig describing the first problem found: no schedule, function or timezone, a negative timeout,
+// run limit, retry count or backoff, or an end time set with Until that isn't after the start time set with After.
+// It gives setup code one place to catch mistakes that Start would otherwise silently ignore.
+func (j *Job) Build() (*Job, error) {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	var problem string
+	switch {
+	case j.Schedule == nil:
+		problem = "no schedule"
+	case j.Fn == nil:
+		problem = "no function, see Execute"
+	case j.Timezone == nil:
+		problem = "no timezone"
+	case j.runTimeout < 0:
+		problem = fmt.Sprintf("negative timeout %v", j.runTimeout)
+	case j.maxRuns < 0:
+		problem = fmt.Sprintf("negative maximum number of runs %d", j.maxRuns)
+	case j.maxAttempts < 0:
+		problem = fmt.Sprintf("negative number of attempts %d", j.maxAttempts)
+	case j.retryBackoff < 0:
+		problem = fmt.Sprintf("negative retry backoff %v", j.retryBackoff)
+	case !j.startTime.IsZero() && !j.endTime.IsZero() && !j.endTime.After(j.startTime):
+		problem = fmt.Sprintf("end time %v is not after start time %v", j.endTime, j.startTime)
+	default:
+		return j, nil
+	}
+	return j, fmt.Errorf("%w: %s", ErrInvalidConfig, problem)
+}
+
+// Config returns a snapshot of the Job's configuration, captured under a single lock
+// so dashboards and admin APIs never observe a half-applied change.
+func (j *Job) Config() JobConfig {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return JobConfig{
+		Name:                 j.Name,
+		ScheduleString:       j.scheduleStr,
+		Blocking:             j.Blocking,
+		TimezoneName:         j.Timezone.String(),
+		LimitPolicy:          j.limitPolicy,
+		EveryNth:             j.everyNth,
+		SkipFirst:            j.skipFirst,
+		RunOnceIfDone:        j.runOnceIfDone,
+		MaxCumulativeRuntime: j.runtimeBudget,
+		SkipIfRunning:        j.skipIfRunning,
+		DelayIfRunning:       j.delayIfRunning,
+		MaxRuns:              j.maxRuns,
+		Timeout:              j.runTimeout,
+		Jitter:               j.jitter,
+		MaxAttempts:          j.maxAttempts,
+		RetryBackoff:         j.retryBackoff,
+		BackoffMultiplier:    j.retryMultiplier,
+		After:                j.startTime,
+		Until:                j.endTime,
+		RunOnStart:           j.runOnStart,
+		RunOnStop:            j.runOnStop,
+		CatchUp:              j.catchUp,
+		AlignFirstRun:        j.alignFirst,
+		DryRun:               j.dryRun,
+	}
+}
+
+// ScheduleString returns the schedule string the Job was built from, as it was given.
+func (j *Job) ScheduleString() string {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.scheduleStr
+}
+
+// HasSeconds reports whether the Job's schedule is a cron expression with a leading seconds field,
+// the 6-field form or the 7-field one with milliseconds, or for Schedules whether any of them is.
+// Descriptors such as "@daily" and intervals don't count, even "@every 30s", and neither does a timezone prefix.
+func (j *Job) HasSeconds() bool {
+	for _, fields := range scheduleParts(j.ScheduleString()) {
+		if len(fields) >= 6 && !strings.HasPrefix(fields[0], "@") {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the Job for logs and debugging, for example
+// Job{name=backup schedule="0 9 * * *" tz=America/New_York blocking=false running=true next=2024-01-02T09:00:00-05:00}.
+// next is "none" when the schedule never fires again.
+func (j *Job) String() string {
+	next := "none"
+	if at := j.NextRun(); !at.IsZero() {
+		next = at.Format(time.RFC3339Nano)
+	}
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return fmt.Sprintf("Job{name=%s schedule=%q tz=%s blocking=%t running=%t next=%s}",
+		j.Name, j.scheduleStr, j.Timezone, j.Blocking, j.isRunning, next)
+}
+
+// LastRun returns when the Job's function was last invoked, or the zero time if it never has.
+// It is recorded as the function is about to be called, after any wait for DelayIfRunning or a concurrency
+// limit, so for blocking and non-blocking jobs alike it is when the run started, not when it finished.
+func (j *Job) LastRun() time.Time {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.lastRun
+}
+
+// RunCount returns how many times the Job's function has been invoked, for blocking and non-blocking jobs alike.
+// The count survives Stop and Start; only RestoreState changes it.
+func (j *Job) RunCount() uint64 {
+	return j.runCount.Load()
+}
+
+// State returns the Job's persistable runtime state.
+func (j *Job) State() JobState {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return JobState{LastRun: j.lastRun, RunCount: j.runCount.Load()}
+}
+
+// RestoreState loads state previously returned by State, for example after a process restart.
+func (j *Job) RestoreState(state JobState) *Job {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.lastRun = state.LastRun
+	j.runCount.Store(state.RunCount)
+	return j
+}
+
+// Start initiates the execution of the Job according to its schedule.
+// The job runs either synchronously or asynchronously based on its Blocking setting.
+func (j *Job) Start() {
+	_ = j.StartE()
+}
+
+// StartE is like Start but reports when the Job can't be started.
+// A Job that was stopped can be started again: it gets a fresh context derived from the one
+// given to WithContext, or from context.Background. If that parent context is itself done,
+// StartE returns ErrContextDone and the Job is not marked as running;
+// see RunOnceIfDone for running the function once in that case.
+// Starting a Job without a function, one that is already running or one that has used up its MaxRuns
+// does nothing and returns ErrNoFunc, ErrAlreadyRunning or ErrMaxRunsReached. A Job whose end time set
+// with Until has already passed calls OnStart, then stops straight away and returns ErrEndTimeReached.
+func (j *Job) StartE() error {
+	exited := make(chan struct{})
+	done, err := j.begin(exited)
+	if done == nil {
+		// no scheduling loop to wait for
+		close(exited)
+		return err
+	}
+	go func() {
+		defer close(exited)
+		j.loop(done)
+	}()
+	return nil
+}
+
+// begin marks the Job as running and returns the channel that closes when it should stop.
+// It returns a nil channel if the Job can't or needn't be started.
+// exited is the channel the caller closes once its scheduling loop returns, or nil if it has none.
+func (j *Job) begin(exited chan struct{}) (<-chan struct{}, error) {
+	j.mutex.Lock()
+	if j.Fn == nil {
+		j.mutex.Unlock()
+		return nil, ErrNoFunc
+	}
+	if j.isRunning {
+		j.mutex.Unlock()
+		return nil, ErrAlreadyRunning
+	}
+	if j.maxRuns > 0 && j.runCount.Load() >= uint64(j.maxRuns) {
+		j.mutex.Unlock()
+		return nil, ErrMaxRunsReached
+	}
+	if j.Ctx.Err() != nil && j.parent.Err() == nil {
+		// stopped earlier, so start over with a fresh context from the same parent
+		j.Ctx, j.cancelFunc = context.WithCancel(j.parent)
+	}
+	if j.Ctx.Err() != nil {
+		runOnce := j.runOnceIfDone
+		j.mutex.Unlock()
+		if runOnce {
+			j.run(j.now())
+		}
+		return nil, ErrContextDone
+	}
+	j.isRunning = true
+	// an engine that drove an earlier run sets rearm again when it takes the Job back
+	j.rearm = nil
+	j.stopCause = nil
+	j.occurrences = 0
+	j.skipPending = j.skipFirst
+	j.ended = &once{}
+	j.halted = make(chan struct{})
+	j.exited = exited
+	j.retired = nil
+	j.firstArm = nil
+	done := j.Ctx.Done()
+	onStart := j.onStart
+	logger, name, scheduleStr := j.log(), j.Name, j.scheduleStr
+	j.mutex.Unlock()
+
+	logger.Info("cron: job started", "job", name, "schedule", scheduleStr, "next", j.NextRun())
+	if onStart != nil {
+		onStart()
+	}
+
+	// settled here rather than by the scheduling loop so FirstRunAt is known as soon as Start returns
+	var first *armed
+	firstRunAt := j.clockNow()
+	runFirst := j.startsWithRun()
+	if !runFirst {
+		at, intended, _ := j.arm(time.Time{})
+		first, firstRunAt = &armed{at: at, intended: intended}, at
+	}
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.runFirst, j.firstArm, j.firstRunAt = runFirst, first, firstRunAt
+	if !j.isRunning {
+		// stopped already, by the first arm finding the end time set with Until passed or by a concurrent Stop
+		return nil, j.stopCause
+	}
+	return done, nil
+}
+
+// loop waits for each scheduled time and fires the Job until done is closed.
+func (j *Job) loop(done <-chan struct{}) {
+	if j.takeRunFirst() {
+		j.dispatch(j.clockNow(), false, true)
+		// Stop may have been called during the run
+		select {
+		case <-done:
+			next := j.retarget(done)
+			if next == nil {
+				j.finish(done)
+				return
+			}
+			done = next
+		default:
+		}
+	}
+	// prev is the scheduled time that fired last, the next one follows on from it
+	var prev time.Time
+	for {
+		currentRun, intended, now := j.arm(prev)
+		// a zero time means the schedule has nothing upcoming, so only a wake-up can change that
+		var timer Timer
+		var expired <-chan time.Time
+		if !currentRun.IsZero() {
+			timer = j.newTimer(currentRun.Sub(now))
+			expired = timer.C()
+		}
+		select {
+		case <-expired:
+			j.tick(currentRun, true)
+			prev = intended
+		case <-j.wake:
+			// something the schedule depends on changed, so start over from now
+			stopTimer(timer)
+			prev = time.Time{}
+		case <-done:
+			stopTimer(timer)
+			next := j.retarget(done)
+			if next == nil {
+				j.finish(done)
+				return
+			}
+			done = next
+		}
+	}
+}
+
+// notify makes a running Job recompute its next fire time, for example after something
+// its schedule depends on has changed.
+func (j *Job) notify() {
+	j.mutex.RLock()
+	rearm := j.rearm
+	j.mutex.RUnlock()
+	if rearm != nil {
+		rearm()
+		return
+	}
+	select {
+	case j.wake <- struct{}{}:
+	default:
+	}
+}
+
+// nextFire returns the next time the Job's schedule fires, interpreted in the Job's timezone.
+func (j *Job) nextFire() time.Time {
+	return j.nextFireAfter(j.notBefore(j.clockNow()))
+}
+
+// notBefore returns t, or the Job's start time set with After if that is later.
+func (j *Job) notBefore(t time.Time) time.Time {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	if j.startTime.After(t) {
+		return j.startTime
+	}
+	return t
+}
+
+// newTimer returns a timer from the Job's Clock that fires after d.
+func (j *Job) newTimer(d time.Duration) Timer {
+	j.mutex.RLock()
+	clock := j.clock
+	j.mutex.RUnlock()
+	return clock.NewTimer(d)
+}
+
+// nextFireAfter returns the first fire time after t.
+// robfig schedules evaluate their fields in the location of the time they're given,
+// so t is always converted to the Job's timezone first, whatever location it carries.
+// Fire times excluded with Skip or SkipFunc are passed over.
+func (j *Job) nextFireAfter(t time.Time) time.Time {
+	j.mutex.RLock()
+	schedule, loc := j.Schedule, j.Timezone
+	skipDates, skipFunc := j.skipDates, j.skipFunc
+	j.mutex.RUnlock()
+	// Schedule has a next function that tells you when to run the job next
+	// https://pkg.go.dev/github.com/robfig/cron#Schedule
+	next := schedule.Next(t.In(loc))
+	for i := 0; !next.IsZero(); i++ {
+		if i == maxExcluded {
+			return time.Time{}
+		}
+		if skipDates[dateOf(next)] {
+			// nothing else that day can fire, so carry on from its last instant
+			y, m, d := next.Date()
+			next = schedule.Next(time.Date(y, m, d+1, 0, 0, 0, 0, loc).Add(-time.Nanosecond))
+			continue
+		}
+		if skipFunc != nil && skipFunc(next) {
+			next = schedule.Next(next)
+			continue
+		}
+		break
+	}
+	return next
+}
+
+// maxExcluded bounds how many consecutive fire times Skip and SkipFunc may pass over
+// before the schedule is taken to never fire again.
+const maxExcluded = 100000
+
+// date is a calendar day, see Skip.
+type date struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+// dateOf returns the calendar day of t in its own location.
+func dateOf(t time.Time) date {
+	y, m, d := t.Date()
+	return date{y, m, d}
+}
+
+// Skip excludes whole days from the schedule, for example public holidays: a fire time falling on one of
+// dates, in the Job's timezone, is passed over for the following scheduled time. Each date is taken as the
+// calendar day it has in its own location, so time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC) excludes
+// Christmas whatever the Job's timezone. Calling Skip again adds to the excluded days.
+func (j *Job) Skip(dates ...time.Time) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	// copied so a nextFireAfter in progress keeps reading the previous set
+	skipDates := make(map[date]bool, len(j.skipDates)+len(dates))
+	for d := range j.skipDates {
+		skipDates[d] = true
+	}
+	for _, t := range dates {
+		skipDates[dateOf(t)] = true
+	}
+	j.skipDates = skipDates
+	return j
+}
+
+// SkipFunc excludes every fire time for which exclude returns true, passing over it for the following
+// scheduled time. exclude is given fire times in the Job's timezone, for example to plug in a holiday calendar.
+// Unlike SkipWhen, it shapes the schedule itself, so NextRun and NextRuns leave the excluded times out.
+// After 100000 excluded fire times in a row the schedule is taken to never fire again. Passing nil removes it.
+func (j *Job) SkipFunc(exclude func(t time.Time) bool) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.skipFunc = exclude
+	return j
+}
+
+// arm computes the Job's next fire time and records it as the one being waited for. It returns that time,
+// jitter included, the scheduled time it was drawn from, and the instant it was computed at, which timers
+// should be measured against so the time taken in between doesn't delay them.
+// prev is the scheduled time that last fired, or the zero time to start over from now. The next fire time
+// follows on from prev rather than from when the timer actually went off, so the lag of each wake-up doesn't
+// add up over time, as it would for Every; it starts over from now if a run overran the next fire time
+// or for fixed-delay jobs, which are measured from the end of each run.
+func (j *Job) arm(prev time.Time) (at, intended, now time.Time) {
+	j.mutex.Lock()
+	now = j.clock.Now()
+	if first := j.firstArm; first != nil && prev.IsZero() {
+		j.firstArm = nil
+		j.mutex.Unlock()
+		return first.at, first.intended, now
+	}
+	skip := j.skipPending
+	j.skipPending = false
+	fixedDelay := j.fixedDelay
+	interval, isInterval := j.Schedule.(intervalSchedule)
+	align, loc := j.alignFirst && isInterval && !fixedDelay, j.Timezone
+	j.mutex.Unlock()
+
+	if !prev.IsZero() && !fixedDelay {
+		if at = j.nextFireAfter(j.notBefore(prev)); !at.After(now) {
+			at = time.Time{}
+		}
+	}
+	if at.IsZero() {
+		from := j.notBefore(now)
+		if align {
+			from = alignDown(from, time.Duration(interval), loc)
+		}
+		at = j.nextFireAfter(from)
+	}
+	if skip && !at.IsZero() {
+		at = j.nextFireAfter(at)
+	}
+	j.mutex.RLock()
+	end := j.endTime
+	j.mutex.RUnlock()
+	if !end.IsZero() && !at.IsZero() && !at.Before(end) {
+		j.stop(ErrEndTimeReached)
+		at = time.Time{}
+	}
+	intended = at
+	at = j.addJitter(at)
+	j.mutex.Lock()
+	j.armedAt = at
+	j.mutex.Unlock()
+	return at, intended, now
+}
+
+// armed is a fire time as arm returns it, see Job.firstArm.
+type armed struct {
+	at, intended time.Time
+}
+
+// takeRunFirst reports whether the Job should run as soon as its scheduling loop starts, as begin found,
+// and clears that so it is only done once per Start.
+func (j *Job) takeRunFirst() bool {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	runFirst := j.runFirst
+	j.runFirst = false
+	return runFirst
+}
+
+// alignDown returns the latest multiple of d, counted on loc's wall clock, that is not after t.
+// Intervals that divide a day, such as a minute or an hour, line up with the clock in loc.
+func alignDown(t time.Time, d time.Duration, loc *time.Location) time.Time {
+	_, offset := t.In(loc).Zone()
+	shift := time.Duration(offset) * time.Second
+	return t.Add(shift).Truncate(d).Add(-shift)
+}
+
+// addJitter delays a fire time by a random amount up to the Job's jitter, see WithJitter.
+// The delay is kept short of the following fire time so that occurrence isn't skipped.
+func (j *Job) addJitter(at time.Time) time.Time {
+	j.mutex.RLock()
+	jitter := j.jitter
+	j.mutex.RUnlock()
+	if jitter <= 0 || at.IsZero() {
+		return at
+	}
+	if following := j.nextFireAfter(at); !following.IsZero() && following.Sub(at) <= jitter {
+		jitter = following.Sub(at) - time.Nanosecond
+	}
+	// rand.Rand isn't safe for concurrent use
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return at.Add(time.Duration(j.rng.Int63n(int64(jitter) + 1)))
+}
+
+// WithJitter delays every fire time by a random amount between zero and max, drawn afresh
+// for each tick, so that many instances sharing a schedule don't all fire at the same instant.
+// The delay never reaches the following scheduled time, so no occurrence is skipped.
+// The randomness is seeded per Job; use WithJitterSource to make it deterministic.
+// A value of zero or less removes the jitter.
+func (j *Job) WithJitter(max time.Duration) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.jitter = max
+	if j.rng == nil {
+		j.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return j
+}
+
+// WithJitterSource sets the source WithJitter draws its delays from, for example a fixed seed in tests.
+func (j *Job) WithJitterSource(src rand.Source) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.rng = rand.New(src)
+	return j
+}
+
+// WithTimeout bounds each run: the function's context is cancelled with context.DeadlineExceeded
+// once d has passed, so the function can clean up. Retries from WithRetry share the same deadline.
+// The run's context is derived from the Job's, so Stop still cancels a run in progress.
+// A duration of zero or less removes the bound.
+func (j *Job) WithTimeout(d time.Duration) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.runTimeout = d
+	return j
+}
+
+// SkipIfRunning makes a tick that fires while the previous scheduled run is still in progress
+// drop its run instead of starting an overlapping one, reporting SkipRunning to OnSkip.
+// Unlike SetBlocking, which never overlaps runs but never drops ticks either, it also works for
+// non-blocking jobs. SkippedCount tells how often it happened. Trigger is not affected.
+func (j *Job) SkipIfRunning(enabled bool) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.skipIfRunning = enabled
+	return j
+}
+
+// DelayIfRunning makes a run that comes due while another is still in progress wait for it to return
+// and then start straight away, rather than overlapping it or being dropped like with SkipIfRunning.
+// Runs are serialized even for non-blocking jobs, and a waiting run gives up if the Job's context is done.
+// A WithTimeout deadline only starts counting once the run actually starts.
+func (j *Job) DelayIfRunning(enabled bool) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.delayIfRunning = enabled
+	return j
+}
+
+// MaxRuns makes the Job stop itself once its RunCount reaches n, with ErrMaxRunsReached as its StopCause.
+// Only runs that actually execute count, so ticks dropped by SkipIfRunning or other gates don't.
+// The run that reaches the limit is allowed to finish first. The limit counts runs over the Job's whole life,
+// as RunCount does, so once it is reached StartE refuses to start the Job again with ErrMaxRunsReached and Trigger
+// does nothing, until MaxRuns is raised or RestoreState lowers RunCount. A value of zero or less means unlimited,
+// the default.
+func (j *Job) MaxRuns(n int) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.maxRuns = n
+	return j
+}
+
+// After holds the Job back until t: a Job started earlier waits, and its schedule is then followed from t,
+// so the first run is the schedule's first fire time after t. This suits staged rollouts where every instance
+// starts up front but should only begin running at a coordinated time. RunOnStart doesn't run the function
+// during the wait, and stopping the Job or cancelling its context ends it. The zero time removes the hold.
+func (j *Job) After(t time.Time) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.startTime = t
+	return j
+}
+
+// Until bounds the Job to a window ending at t: once its next fire time is at or after t, the Job stops
+// itself instead of waiting for it, with ErrEndTimeReached as its StopCause. Fire times are compared with t
+// as instants, after being computed in the Job's timezone. Combined with MaxRuns, whichever limit is reached
+// first stops the Job. Starting a Job whose end time has passed returns ErrEndTimeReached from StartE.
+// The zero time removes the bound.
+func (j *Job) Until(t time.Time) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.endTime = t
+	return j
+}
+
+// FirstRunAt returns when the Job first fires after its latest Start, as settled by Start: right away for
+// RunOnStart or CatchUp, and otherwise the first fire time armed, jitter included. It is the zero time
+// if the Job was never started or its schedule had nothing upcoming. Later fires don't change it.
+func (j *Job) FirstRunAt() time.Time {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.firstRunAt
+}
+
+// AlignFirstRun makes a Job built with Every count its interval from the clock rather than from Start,
+// so it first fires on the next multiple of the interval on the wall clock of its timezone: at the top of
+// the next minute for Every(time.Minute), or on the next quarter hour for Every(15 * time.Minute), however
+// late in the interval Start was called. Later fires follow on from it and so stay on that grid.
+// It has no effect on cron expressions and descriptors, which are aligned to the clock anyway, nor on AfterEach.
+func (j *Job) AlignFirstRun(enabled bool) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.alignFirst = enabled
+	return j
+}
+
+// EndTime returns the end of the Job's window set with Until, or the zero time if it has none.
+func (j *Job) EndTime() time.Time {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.endTime
+}
+
+// SkippedCount returns how many ticks SkipIfRunning dropped because the previous run was still in progress.
+func (j *Job) SkippedCount() uint64 {
+	return j.skipped.Load()
+}
+
+// DryRun makes the Job follow its schedule and gates exactly as usual but, instead of calling the function,
+// log each fire time at info level to the Logger set with WithLogger and carry on. Dry runs are counted by
+// DryRunCount rather than RunCount, and none of the hooks around a run are called, which makes it a safe
+// way to check a complex schedule against the real clock before letting it do any work. Trigger dry runs too,
+// and WaitForNextRun returns after a dry run as it would after a real one.
+func (j *Job) DryRun(enabled bool) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.dryRun = enabled
+	return j
+}
+
+// DryRunCount returns how many runs DryRun logged instead of calling the function.
+func (j *Job) DryRunCount() uint64 {
+	return j.dryRuns.Load()
+}
+
+// WithRetry makes a failing run try again, up to maxAttempts attempts in total, waiting backoff between them.
+// Only errors returned by a function set with ExecuteE are retried, not panics, and retrying stops early
+// once the run's context is done. Only the last error reaches OnError. Retries happen within the run, so they
+// delay the next tick of blocking jobs but not of non-blocking ones. A maxAttempts of one or less disables retries.
+func (j *Job) WithRetry(maxAttempts int, backoff time.Duration) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.maxAttempts = maxAttempts
+	j.retryBackoff = backoff
+	return j
+}
+
+// WithBackoffMultiplier grows the WithRetry backoff by multiplier after each retry, for exponential backoff.
+// Values of one or less keep the backoff constant, which is the default.
+func (j *Job) WithBackoffMultiplier(multiplier float64) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.retryMultiplier = multiplier
+	return j
+}
+
+// OnPanic registers a hook called with the value and stack trace of any panic recovered from the Job's function.
+// Panics are always recovered so the Job keeps to its schedule; without a hook they are silently dropped.
+// The hook runs on the goroutine that ran the function.
+func (j *Job) OnPanic(fn func(recovered interface{}, stack []byte)) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.onPanic = fn
+	return j
+}
+
+// Trigger runs the Job's function right away, outside of its schedule, honoring the Blocking setting.
+// It works whether or not the Job was started, bypasses per-tick gates such as Pause and SkipWhen,
+// and counts towards RunCount and LastRun like a scheduled run. It does nothing if the function is nil.
+// The function receives the Job's context, which is cancelled if the Job was stopped.
+func (j *Job) Trigger() {
+	j.dispatch(j.clockNow(), false, true)
+}
+
+// Pause suspends the Job without stopping it: the schedule keeps ticking and counting occurrences,
+// but each tick is skipped with SkipPaused until Resume is called. Runs already in progress are unaffected.
+func (j *Job) Pause() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.paused = true
+}
+
+// Resume lets a paused Job fire again from its next scheduled tick. Ticks that passed while paused are not made up.
+func (j *Job) Resume() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.paused = false
+}
+
+// WaitForNextRun blocks until the Job's function next returns, whether from a scheduled run or Trigger,
+// so tests can wait for a run instead of sleeping. Runs already in progress when it is called count.
+// It returns ErrStopped if the Job was running and stops first, or ctx's error if ctx is done first.
+func (j *Job) WaitForNextRun(ctx context.Context) error {
+	j.mutex.Lock()
+	if j.ran == nil {
+		j.ran = make(chan struct{})
+	}
+	ran := j.ran
+	var halted chan struct{}
+	if j.isRunning {
+		halted = j.halted
+	}
+	j.mutex.Unlock()
+	select {
+	case <-ran:
+		return nil
+	case <-halted:
+		return ErrStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// IsRunning reports whether the Job's scheduling loop is active.
+// It is false before Start, after Stop, and once the Job's context is done.
+func (j *Job) IsRunning() bool {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.isRunning
+}
+
+// NextRun returns the next time the Job's schedule fires after now, or after its start time set with After,
+// in the Job's timezone, or the zero time if it never fires again. Unlike ArmedFireTime it only consults
+// the schedule, so it works whether or not the Job is running.
+func (j *Job) NextRun() time.Time {
+	return j.nextFireAfter(j.notBefore(j.now()))
+}
+
+// NextRuns returns up to n upcoming fire times, which is handy for previewing a complex schedule.
+// Fewer are returned if the schedule stops firing.
+func (j *Job) NextRuns(n int) []time.Time {
+	var runs []time.Time
+	at := j.notBefore(j.now())
+	for i := 0; i < n; i++ {
+		if at = j.nextFireAfter(at); at.IsZero() {
+			break
+		}
+		runs = append(runs, at)
+	}
+	return runs
+}
+
+// NextAfter returns the first time the Job's schedule fires after t, in the Job's timezone, or the zero time
+// if it never fires again. Like NextRun it passes over days excluded with Skip and SkipFunc, but it ignores
+// the start and end times set with After and Until, answering for any reference time rather than just now.
+func (j *Job) NextAfter(t time.Time) time.Time {
+	return j.nextFireAfter(t)
+}
+
+// prevScanLimit bounds how far back PrevBefore looks for a fire time.
+const prevScanLimit = 366 * 24 * time.Hour
+
+// PrevBefore returns the last time the Job's schedule fired before t, in the Job's timezone, and false
+// if it didn't fire in the year before t. robfig schedules can only be evaluated forward, so this is
+// a best-effort search: it steps back in doubling windows until one contains a fire time, then bisects
+// down to the latest one, costing around a hundred evaluations of the schedule at most however dense it is.
+// Like NextAfter it honours Skip and SkipFunc and ignores After and Until. Intervals set with Every
+// have no fixed grid, so for them it simply returns t minus the interval.
+func (j *Job) PrevBefore(t time.Time) (time.Time, bool) {
+	j.mutex.RLock()
+	schedule, loc := j.Schedule, j.Timezone
+	j.mutex.RUnlock()
+	if d, ok := schedule.(intervalSchedule); ok {
+		return t.Add(-time.Duration(d)).In(loc), true
+	}
+
+	// find a point whose next fire time is before t, so at least one fire time lies in between
+	lo := time.Time{}
+	for w := time.Second; ; w *= 2 {
+		if w > prevScanLimit {
+			w = prevScanLimit
+		}
+		if next := j.nextFireAfter(t.Add(-w)); !next.IsZero() && next.Before(t) {
+			lo = t.Add(-w)
+			break
+		}
+		if w == prevScanLimit {
+			return time.Time{}, false
+		}
+	}
+	// the next fire time only moves forward with the point it is taken from, so bisect for the
+	// latest point whose next fire time is still before t; that fire time is the one before t
+	hi := t
+	for hi.Sub(lo) > time.Nanosecond {
+		mid := lo.Add(hi.Sub(lo) / 2)
+		if next := j.nextFireAfter(mid); !next.IsZero() && next.Before(t) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return j.nextFireAfter(lo), true
+}
+
+// ArmedFireTime returns the time the running scheduling loop is currently waiting for.
+// It reflects the scheduler's real state, which can differ from the schedule alone,
+// and reports false when the Job isn't running or its schedule never fires again.
+func (j *Job) ArmedFireTime() (time.Time, bool) {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	if !j.isRunning || j.armedAt.IsZero() {
+		return time.Time{}, false
+	}
+	return j.armedAt, true
+}
+
+// tick handles one expiry of the Job's timer: it applies the Job's gates and dispatches the run.
+// Blocking jobs run before tick returns, non-blocking jobs on their own goroutine. See dispatch for wait.
+func (j *Job) tick(fireTime time.Time, wait bool) {
+	if !j.shouldFire() {
+		return
+	}
+	j.mutex.RLock()
+	exclusive := j.skipIfRunning
+	j.mutex.RUnlock()
+	if exclusive && !j.active.CompareAndSwap(false, true) {
+		j.skipped.Add(1)
+		j.skip(SkipRunning)
+		return
+	}
+	j.dispatch(fireTime, exclusive, wait)
+}
+
+// dispatch runs the Job's function for fireTime, before returning for blocking jobs
+// and on its own goroutine otherwise. If exclusive, the active flag is cleared once the run returns.
+// Unless wait is set, a run that finds the Scheduler's pool full is dropped rather than waiting for room,
+// which a heapEngine needs since waiting would hold up every other job it drives.
+func (j *Job) dispatch(fireTime time.Time, exclusive, wait bool) {
+	isBlocking := j.blocking()
+
+	// counted before dispatching so a concurrent drain can't miss a run that is about to start
+	j.inflight.add()
+	finish := func() {
+		if exclusive {
+			j.active.Store(false)
+		}
+		j.inflight.done()
+	}
+	j.mutex.RLock()
+	pool, ctx := j.pool, j.Ctx
+	j.mutex.RUnlock()
+	switch {
+	case isBlocking:
+		defer finish()
+		j.run(fireTime)
+	case pool != nil:
+		task := poolTask{
+			run: func() {
+				defer finish()
+				j.run(fireTime)
+			},
+			drop: func() {
+				defer finish()
+				j.drop(fireTime)
+			},
+		}
+		if !pool.submit(ctx, task, wait) {
+			task.drop()
+		}
+	default:
+		go func() {
+			defer finish()
+			j.run(fireTime)
+		}()
+	}
+}
+
+// setPool routes the Job's non-blocking executions through p, or back onto their own goroutines if p is nil.
+func (j *Job) setPool(p *workerPool) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.pool = p
+}
+
+// drop reports a tick discarded because a concurrency limit was saturated to OnDrop and the Job's Metrics.
+func (j *Job) drop(fireTime time.Time) {
+	j.mutex.RLock()
+	onDrop, metrics, name := j.onDrop, j.meter(), j.Name
+	j.mutex.RUnlock()
+	metrics.IncSkip(name)
+	if onDrop != nil {
+		onDrop(fireTime)
+	}
+}
+
+// startsWithRun reports whether the Job runs as soon as it starts, see RunOnStart and CatchUp.
+// It doesn't while the Job is held back by After.
+func (j *Job) startsWithRun() bool {
+	j.mutex.RLock()
+	now := j.clock.Now()
+	held := j.startTime.After(now)
+	runOnStart, catchUp, lastRun := j.runOnStart, j.catchUp, j.lastRun
+	j.mutex.RUnlock()
+	if held {
+		return false
+	}
+	if runOnStart {
+		return true
+	}
+	if !catchUp || lastRun.IsZero() {
+		return false
+	}
+	missed := j.nextFireAfter(lastRun)
+	return !missed.IsZero() && !missed.After(now)
+}
+
+// blocking reports whether runs happen on the scheduling goroutine.
+// Fixed-delay jobs always do, since their next fire time is measured from the end of the run.
+func (j *Job) blocking() bool {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.Blocking || j.fixedDelay
+}
+
+// retarget is called once done has closed. If it belonged to a context that WithContext replaced
+// while the Job kept running, it returns the current context's done channel to wait on instead,
+// otherwise nil.
+func (j *Job) retarget(done <-chan struct{}) <-chan struct{} {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if !j.retired[done] {
+		return nil
+	}
+	delete(j.retired, done)
+	return j.Ctx.Done()
+}
+
+// finish marks the Job as stopped once the context behind done is finished,
+// which may have been cancelled by its parent rather than by Stop.
+// It does nothing if the Job has since moved on to a different context.
+func (j *Job) finish(done <-chan struct{}) {
+	j.mutex.Lock()
+	current := j.Ctx.Done() == done
+	if current {
+		j.isRunning = false
+		j.armedAt = time.Time{}
+		j.rearm = nil
+	}
+	cause := j.stopCause
+	if cause == nil {
+		cause = j.Ctx.Err()
+	}
+	ended, exit := j.ended, j.stopped(cause)
+	j.mutex.Unlock()
+	if current {
+		ended.notify(exit)
+	}
+}
+
+// shouldFire reports whether the current tick should run the job's function.
+func (j *Job) shouldFire() bool {
+	j.mutex.Lock()
+	j.occurrences++
+	decimated := j.everyNth > 1 && j.occurrences%j.everyNth != 0
+	j.mutex.Unlock()
+	if decimated {
+		return false
+	}
+
+	j.mutex.RLock()
+	cond, ctx := j.edgeCond, j.Ctx
+	deps, lastRun := j.deps, j.lastRun
+	skipWhen := j.skipWhen
+	hasFn := j.Fn != nil
+	paused := j.paused
+	logger, name := j.log(), j.Name
+	j.mutex.RUnlock()
+
+	if paused {
+		j.skip(SkipPaused)
+		return false
+	}
+
+	// Fn is exported, so it can be set to nil behind Execute's back while the job runs
+	if !hasFn {
+		logger.Error("cron: job has no function, tick skipped", "job", name)
+		j.skip(SkipNoFunc)
+		return false
+	}
+
+	if skipWhen != nil && skipWhen() {
+		j.skip(SkipPredicate)
+		return false
+	}
+
+	for _, dep := range deps {
+		dep.mutex.RLock()
+		ready := dep.lastRun.After(lastRun)
+		dep.mutex.RUnlock()
+		if !ready {
+			j.skip(SkipWaitingOnDeps)
+			return false
+		}
+	}
+
+	if cond != nil {
+		// evaluated outside the lock so cond may safely call back into the Job
+		current := cond(ctx)
+		j.mutex.Lock()
+		rising := current && !j.edgeState
+		j.edgeState = current
+		j.mutex.Unlock()
+		if !rising {
+			return false
+		}
+	}
+	return true
+}
+
+// skip reports a skipped tick to the OnSkip callback, if any.
+func (j *Job) skip(reason string) {
+	j.mutex.RLock()
+	onSkip, metrics, name := j.onSkip, j.meter(), j.Name
+	j.mutex.RUnlock()
+	metrics.IncSkip(name)
+	if onSkip != nil {
+		onSkip(reason)
+	}
+}
+
+// run invokes the Job's function once for the given scheduled fire time
+// and reports the execution to the observer, if any.
+func (j *Job) run(fireTime time.Time) {
+	j.mutex.RLock()
+	ctx := j.Ctx
+	j.mutex.RUnlock()
+	j.runWith(ctx, fireTime)
+}
+
+// runWith is run with an explicit context instead of the Job's own.
+func (j *Job) runWith(ctx context.Context, fireTime time.Time) {
+	j.mutex.RLock()
+	fn, task, more, observer := j.Fn, j.task, j.more, j.observer
+	limiter, policy := j.limiter, j.limitPolicy
+	onCancel, onError := j.onCancel, j.onError
+	onPanic, onBeforeRun, onAfterRun := j.onPanic, j.onBeforeRun, j.onAfterRun
+	attempts, backoff, multiplier := j.maxAttempts, j.retryBackoff, j.retryMultiplier
+	timeout, serialize := j.runTimeout, j.delayIfRunning
+	logger, metrics, name, clock := j.log(), j.meter(), j.Name, j.clock
+	tracer, spanName := j.tracer, j.Name
+	if spanName == "" {
+		spanName = j.scheduleStr
+	}
+	dryRun, values := j.dryRun, j.values
+	j.mutex.RUnlock()
+
+	if fn == nil {
+		return
+	}
+	if dryRun {
+		count := j.dryRuns.Add(1)
+		logger.Info("cron: job dry run", "job", name, "fire_time", fireTime, "dry_run", count)
+		j.mutex.Lock()
+		j.signalRan()
+		j.mutex.Unlock()
+		return
+	}
+	if task == nil {
+		task = func(ctx context.Context) error {
+			fn(ctx)
+			return nil
+		}
+	}
+	if len(more) > 0 {
+		first := task
+		task = func(ctx context.Context) error {
+			err := first(ctx)
+			for _, fn := range more {
+				fn(ctx)
+			}
+			return err
+		}
+	}
+
+	if serialize {
+		select {
+		case j.serial <- struct{}{}:
+			defer func() { <-j.serial }()
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	if limiter != nil {
+		if !acquire(ctx, limiter, policy) {
+			j.drop(fireTime)
+			return
+		}
+		defer func() { <-limiter }()
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	for _, v := range values {
+		ctx = context.WithValue(ctx, v.key, v.val)
+	}
+
+	if onCancel != nil {
+		finished := make(chan struct{})
+		defer close(finished)
+		go func() {
+			select {
+			case <-ctx.Done():
+				onCancel()
+			case <-finished:
+			}
+		}()
+	}
+
+	start := clock.Now()
+	j.mutex.Lock()
+	// checked and counted together so concurrent runs can't overshoot MaxRuns
+	if j.maxRuns > 0 && j.runCount.Load() >= uint64(j.maxRuns) {
+		j.mutex.Unlock()
+		return
+	}
+	j.lastRun = start
+	followers := j.followers
+	count := j.runCount.Add(1)
+	lastAllowed := j.maxRuns > 0 && count >= uint64(j.maxRuns)
+	j.mutex.Unlock()
+	for _, follower := range followers {
+		follower.notify()
+	}
+	logger.Debug("cron: job running", "job", name, "fire_time", fireTime, "run", count)
+	// a new variable, as the OnCancel watcher above is still reading ctx
+	runCtx := withInfo(ctx, JobInfo{Name: name, FireTime: fireTime, Run: count})
+	runCtx, endSpan := startSpan(runCtx, tracer, spanName)
+	if onBeforeRun != nil {
+		onBeforeRun(runCtx)
+	}
+	began := clock.Now()
+	err := retry(runCtx, clock, task, attempts, backoff, multiplier)
+	duration := clock.Now().Sub(began)
+	metrics.IncRun(name)
+	metrics.ObserveDuration(name, duration)
+	if err != nil {
+		metrics.IncError(name)
+	}
+	if onAfterRun != nil {
+		onAfterRun(runCtx, duration)
+	}
+	endSpan(err)
+	var panicked *panicError
+	if errors.As(err, &panicked) {
+		logger.Error("cron: job panicked", "job", name, "panic", panicked.value, "stack", string(panicked.stack))
+		if onPanic != nil {
+			onPanic(panicked.value, panicked.stack)
+		}
+	} else if err != nil {
+		logger.Error("cron: job failed", "job", name, "error", err)
+		if onError != nil {
+			onError(err)
+		}
+	}
+	if observer != nil {
+		observer(fireTime, duration, err)
+	}
+
+	j.mutex.Lock()
+	j.totalRuntime += duration
+	j.signalRan()
+	exceeded := j.runtimeBudget > 0 && j.totalRuntime > j.runtimeBudget
+	j.mutex.Unlock()
+	if exceeded {
+		j.stop(ErrRuntimeBudgetExceeded)
+	} else if lastAllowed {
+		j.stop(ErrMaxRunsReached)
+	}
+}
+
+// signalRan wakes everyone waiting in WaitForNextRun. The caller holds the mutex.
+func (j *Job) signalRan() {
+	if j.ran != nil {
+		close(j.ran)
+		j.ran = nil
+	}
+}
+
+// panicError is the error a run reports when the Job's function panicked.
+type panicError struct {
+	value interface{}
+	stack []byte
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("cron: job panicked: %v", e.value)
+}
+
+// call runs task, turning a panic into a *panicError.
+// Blocking jobs run on the scheduling goroutine, so this is what keeps one bad run
+// from killing the loop; non-blocking runs are protected the same way.
+func call(task func(ctx context.Context) error, ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &panicError{value: r, stack: debug.Stack()}
+		}
+	}()
+	return task(ctx)
+}
+
+// retry calls task until it succeeds, panics, uses up its attempts or ctx is done, and returns the last error.
+func retry(ctx context.Context, clock Clock, task func(ctx context.Context) error, attempts int, backoff time.Duration, multiplier float64) error {
+	err := call(task, ctx)
+	for attempt := 1; attempt < attempts && err != nil; attempt++ {
+		var panicked *panicError
+		if errors.As(err, &panicked) {
+			return err
+		}
+		timer := clock.NewTimer(backoff)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			stopTimer(timer)
+			return err
+		}
+		if multiplier > 1 {
+			backoff = time.Duration(float64(backoff) * multiplier)
+		}
+		err = call(task, ctx)
+	}
+	return err
+}
+
+// acquire takes a slot from limiter according to policy and reports whether it got one.
+func acquire(ctx context.Context, limiter chan struct{}, policy LimitPolicy) bool {
+	if policy == LimitSkip {
+		select {
+		case limiter <- struct{}{}:
+			return true
+		default:
+			return false
+		}
+	}
+	select {
+	case limiter <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Stop halts the execution of the Job.
+// It cancels the Job's context, effectively stopping the running task. The Job can be started again later.
+// Stop doesn't wait for a run in progress to return, see StopAndWait for that,
+// and RunOnStop for running the function one final time.
+func (j *Job) Stop() {
+	if j.halt() {
+		j.finalRun(context.Background())
+	}
+}
+
+// halt is Stop without the final run, and reports whether RunOnStop asks for one.
+func (j *Job) halt() bool {
+	if !j.stop(nil) {
+		return false
+	}
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.runOnStop
+}
+
+// finalRun runs the function one last time for RunOnStop, with a context derived from ctx
+// that also times out after finalRunTimeout.
+func (j *Job) finalRun(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, finalRunTimeout)
+	defer cancel()
+	j.runWith(ctx, j.clockNow())
+}
+
+// StopAndWait is like Stop but also blocks until the Job is quiesced: its scheduling loop has returned
+// and no run of its function is in progress, blocking or not. If ctx is done first, it returns ctx's error;
+// the Job is stopped either way.
+func (j *Job) StopAndWait(ctx context.Context) error {
+	j.mutex.RLock()
+	exited := j.exited
+	j.mutex.RUnlock()
+	j.Stop()
+	if exited != nil {
+		select {
+		case <-exited:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return j.inflight.wait(ctx)
+}
+
+// stop cancels a running Job's context, recording cause as the reason.
+// It reports whether the Job was running.
+func (j *Job) stop(cause error) bool {
+	j.mutex.Lock()
+	if !j.isRunning {
+		j.mutex.Unlock()
+		return false
+	}
+	j.isRunning = false
+	j.stopCause = cause
+	j.cancelFunc()
+	ended, exit := j.ended, j.stopped(cause)
+	j.mutex.Unlock()
+	ended.notify(exit)
+	return true
+}
+
+// once runs a callback at most once; a nil *once never runs it.
+type once struct {
+	sync.Once
+}
+
+// notify calls fn unless it is nil or this once already ran.
+func (o *once) notify(fn func()) {
+	if o != nil && fn != nil {
+		o.Do(fn)
+	}
 }
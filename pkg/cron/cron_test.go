@@ -2,10 +2,23 @@ package cron
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
+// fastJob returns a job that fires every d.
+func fastJob(d time.Duration) *Job {
+	return Every(d)
+}
+
 // TestSchedule tests the Schedule function for correct schedule parsing.
 func TestSchedule(t *testing.T) {
 	// Test with a valid schedule string
@@ -23,6 +36,142 @@ func TestSchedule(t *testing.T) {
 	Schedule("invalid-cron-string")
 }
 
+// TestScheduleE tests that ScheduleE reports which field of a bad expression failed.
+func TestScheduleE(t *testing.T) {
+	if job, err := ScheduleE("*/5 * * * * *"); err != nil || job == nil {
+		t.Errorf("ScheduleE rejected a valid schedule: %v", err)
+	}
+
+	tests := []struct {
+		schedule string
+		want     string
+	}{
+		{"60 * * * * *", `seconds field "60" (valid range 0-59)`},
+		{"0 99 * * *", `hours field "99" (valid range 0-23)`},
+		{"99 * * * *", `minutes field "99" (valid range 0-59)`},
+		{"0 0 0 * *", `day of month field "0" (valid range 1-31)`},
+		{"0 0 * * 1-9", `day of week field "1-9" (valid range 0-6)`},
+		{"TZ=America/New_York 0 99 * * *", `hours field "99" (valid range 0-23)`},
+		{"CRON_TZ=UTC 60 * * * * *", `seconds field "60" (valid range 0-59)`},
+	}
+	for _, test := range tests {
+		_, err := ScheduleE(test.schedule)
+		if err == nil {
+			t.Errorf("ScheduleE(%q) did not return an error", test.schedule)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.want) {
+			t.Errorf("ScheduleE(%q) returned %q, want it to mention %q", test.schedule, err, test.want)
+		}
+		if errors.Unwrap(errors.Unwrap(err)) == nil {
+			t.Errorf("ScheduleE(%q) does not wrap the parser error", test.schedule)
+		}
+	}
+
+	for _, schedule := range []string{"* * * *", "1 2 3 4 5 6 7 8"} {
+		_, err := ScheduleE(schedule)
+		if !errors.Is(err, ErrFieldCount) || !strings.Contains(err.Error(), fmt.Sprintf("%q", schedule)) ||
+			strings.Count(err.Error(), "cron:") != 1 {
+			t.Errorf("ScheduleE(%q) returned %v, want a field count error quoting the schedule under one \"cron:\" prefix", schedule, err)
+		}
+	}
+	if _, err := ScheduleE("TZ=Bad/Zone 0 9 * * *"); err == nil || strings.Contains(err.Error(), "field") {
+		t.Errorf("ScheduleE blamed a field for an unknown timezone: %v", err)
+	}
+	if _, err := ScheduleE("0 99 * * *"); errors.Is(err, ErrFieldCount) ||
+		!strings.Contains(err.Error(), `"0 99 * * *"`) || !strings.Contains(err.Error(), "above maximum (23)") {
+		t.Errorf("expected a value error to quote the schedule and the parser's reason, got %v", err)
+	}
+
+	for _, blank := range []string{"", "   ", "\t\n"} {
+		_, err := ScheduleE(blank)
+		if err == nil || !strings.Contains(err.Error(), "empty schedule") {
+			t.Errorf("ScheduleE(%q) returned %v, want an empty schedule error", blank, err)
+		}
+	}
+}
+
+// TestErrors tests that failures can be told apart with errors.Is while keeping their details.
+func TestErrors(t *testing.T) {
+	for _, bad := range []string{"0 99 * * *", "* * * *", "@fortnightly"} {
+		_, err := ScheduleE(bad)
+		var scheduleErr *ScheduleError
+		if !errors.Is(err, ErrInvalidSchedule) || !errors.As(err, &scheduleErr) || scheduleErr.Schedule != bad {
+			t.Errorf("ScheduleE(%q) returned %v, want a *ScheduleError matching ErrInvalidSchedule", bad, err)
+		}
+		if errors.Unwrap(err) == nil {
+			t.Errorf("ScheduleE(%q) does not unwrap to the parser's reason", bad)
+		}
+	}
+	if _, err := EveryE(0); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("EveryE(0) returned %v, want ErrInvalidSchedule", err)
+	}
+	if _, err := ScheduleStrict("* * * * * *"); !errors.Is(err, ErrInvalidSchedule) || !errors.Is(err, ErrFieldCount) {
+		t.Errorf("ScheduleStrict returned %v, want both ErrInvalidSchedule and ErrFieldCount", err)
+	}
+
+	job := Schedule("0 0 1 1 *")
+	if err := job.StartE(); err != ErrNoFunc {
+		t.Errorf("StartE without a function returned %v, want ErrNoFunc", err)
+	}
+	job.Execute(func(ctx context.Context) {})
+	if err := job.StartE(); err != nil {
+		t.Fatalf("StartE returned %v", err)
+	}
+	if err := job.StartE(); err != ErrAlreadyRunning {
+		t.Errorf("StartE on a running job returned %v, want ErrAlreadyRunning", err)
+	}
+
+	waited := make(chan error, 1)
+	go func() { waited <- job.WaitForNextRun(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+	job.Stop()
+	select {
+	case err := <-waited:
+		if err != ErrStopped {
+			t.Errorf("WaitForNextRun returned %v when the job stopped, want ErrStopped", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("expected WaitForNextRun to return when the job stopped")
+	}
+}
+
+// TestScheduleStrict tests that ScheduleStrict and ScheduleWithSeconds pin the number of fields.
+func TestScheduleStrict(t *testing.T) {
+	for _, schedule := range []string{"0 9 * * 1-5", "@daily", "CRON_TZ=America/New_York 0 9 * * *", "0 8 * * *; 30 20 * * 5"} {
+		if _, err := ScheduleStrict(schedule); err != nil {
+			t.Errorf("ScheduleStrict(%q) returned %v", schedule, err)
+		}
+	}
+	for _, schedule := range []string{"* * * * * *", "0 * * * * * *", "0 8 * * *; 0 30 20 * * 5"} {
+		if _, err := ScheduleStrict(schedule); !errors.Is(err, ErrFieldCount) {
+			t.Errorf("ScheduleStrict(%q) returned %v, want ErrFieldCount", schedule, err)
+		}
+	}
+	if _, err := ScheduleStrict("0 8 * * *; 0 30 20 * * 5"); err == nil || !strings.Contains(err.Error(), "found 6") {
+		t.Errorf("expected the field count of the offending schedule, got %v", err)
+	}
+	for _, schedule := range []string{"@every 1s", "0 8 * * *; @every 1h"} {
+		if _, err := ScheduleStrict(schedule); err == nil {
+			t.Errorf("ScheduleStrict(%q) accepted @every", schedule)
+		}
+	}
+	if _, err := ScheduleStrict("0 99 * * *"); err == nil || errors.Is(err, ErrFieldCount) {
+		t.Errorf("expected ScheduleStrict to report an out of range value as such, got %v", err)
+	}
+
+	for _, schedule := range []string{"30 0 9 * * 1-5", "TZ=Europe/Paris 30 0 9 * * 1-5"} {
+		if _, err := ScheduleWithSeconds(schedule); err != nil {
+			t.Errorf("ScheduleWithSeconds(%q) rejected a 6-field expression: %v", schedule, err)
+		}
+	}
+	for _, schedule := range []string{"0 9 * * 1-5", "@daily"} {
+		if _, err := ScheduleWithSeconds(schedule); !errors.Is(err, ErrFieldCount) {
+			t.Errorf("ScheduleWithSeconds(%q) returned %v, want ErrFieldCount", schedule, err)
+		}
+	}
+}
+
 // TestSetBlocking tests the SetBlocking method.
 func TestSetBlocking(t *testing.T) {
 	job := Schedule("*/5 * * * * *")
@@ -57,6 +206,45 @@ func TestWithContext(t *testing.T) {
 	}
 }
 
+// TestWithContextValues tests that values on the supplied context reach the job's function,
+// and that stopping the job cancels its runs' context but not the supplied one.
+func TestWithContextValues(t *testing.T) {
+	type key struct{}
+	parent, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "tenant-a"))
+	defer cancel()
+
+	seen := make(chan context.Context, 1)
+	job := fastJob(10 * time.Millisecond).WithContext(parent).Execute(func(ctx context.Context) {
+		select {
+		case seen <- ctx:
+		default:
+		}
+	})
+	if got := job.Ctx.Value(key{}); got != "tenant-a" {
+		t.Errorf("job.Ctx does not carry the supplied value, got %v", got)
+	}
+
+	job.Start()
+	defer job.Stop()
+	var runCtx context.Context
+	select {
+	case runCtx = <-seen:
+		if got := runCtx.Value(key{}); got != "tenant-a" {
+			t.Errorf("Fn received value %v, want tenant-a", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("job did not run")
+	}
+
+	job.Stop()
+	if parent.Err() != nil {
+		t.Errorf("Stop canceled the supplied context")
+	}
+	if runCtx.Err() == nil || job.Ctx.Err() == nil {
+		t.Errorf("expected Stop to cancel the context the job runs with")
+	}
+}
+
 // TestSetTimezone tests the SetTimezone method.
 func TestSetTimezone(t *testing.T) {
 	job := Schedule("*/5 * * * * *")
@@ -68,6 +256,251 @@ func TestSetTimezone(t *testing.T) {
 	}
 }
 
+// TestSetTimezoneE tests that SetTimezoneE rejects unusable locations and keeps the old one.
+func TestSetTimezoneE(t *testing.T) {
+	job := Schedule("*/5 * * * * *")
+	if _, err := job.SetTimezoneE(nil); err == nil {
+		t.Errorf("SetTimezoneE accepted a nil location")
+	}
+	if job.Timezone != time.UTC {
+		t.Errorf("SetTimezoneE changed the timezone despite an error")
+	}
+
+	loc := time.FixedZone("PST", -8*3600)
+	if _, err := job.SetTimezoneE(loc); err != nil {
+		t.Errorf("SetTimezoneE rejected a valid location: %v", err)
+	}
+	if job.Timezone != loc {
+		t.Errorf("SetTimezoneE did not set the timezone")
+	}
+}
+
+// TestSetTimezoneName tests that SetTimezoneName loads named timezones and rejects unknown ones.
+func TestSetTimezoneName(t *testing.T) {
+	job, err := Schedule("0 9 * * *").SetTimezoneName("America/New_York")
+	if err != nil {
+		t.Fatalf("SetTimezoneName failed: %v", err)
+	}
+	if job.Timezone.String() != "America/New_York" {
+		t.Errorf("expected America/New_York, got %v", job.Timezone)
+	}
+
+	if _, err := job.SetTimezoneName("Mars/Olympus_Mons"); err == nil {
+		t.Errorf("SetTimezoneName accepted an unknown timezone")
+	}
+	if job.Timezone.String() != "America/New_York" {
+		t.Errorf("expected a failed SetTimezoneName to keep the timezone, got %v", job.Timezone)
+	}
+}
+
+// TestSetDefaultTimezone tests that the default timezone applies to jobs created afterwards only.
+func TestSetDefaultTimezone(t *testing.T) {
+	loc, _ := time.LoadLocation("America/Chicago")
+	before := Schedule("0 9 * * *")
+	SetDefaultTimezone(loc)
+	defer SetDefaultTimezone(nil)
+
+	if job := Every(time.Minute); job.Timezone != loc {
+		t.Errorf("expected a new job to take the default timezone, got %v", job.Timezone)
+	}
+	if before.Timezone != time.UTC {
+		t.Errorf("expected an existing job to keep UTC, got %v", before.Timezone)
+	}
+	SetDefaultTimezone(nil)
+	if job := Schedule("0 9 * * *"); job.Timezone != time.UTC {
+		t.Errorf("expected SetDefaultTimezone(nil) to restore UTC, got %v", job.Timezone)
+	}
+}
+
+// TestSameSchedule tests that SameSchedule compares canonical schedules and timezones, and Equal names and blocking too.
+func TestSameSchedule(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	same := [][2]*Job{
+		{Schedule("0 9 * * MON-FRI"), Schedule(" 0  9 * * mon-fri")},
+		{Schedule("@every 60s"), Every(time.Minute)},
+		{Schedule("@daily").SetName("a").Execute(func(ctx context.Context) {}), Schedule("@daily").SetName("b")},
+	}
+	for _, pair := range same {
+		if !pair[0].SameSchedule(pair[1]) {
+			t.Errorf("expected %q and %q to be the same schedule", pair[0].scheduleStr, pair[1].scheduleStr)
+		}
+	}
+	different := [][2]*Job{
+		{Schedule("0 9 * * *"), Schedule("0 10 * * *")},
+		{Schedule("0 9 * * *"), Schedule("0 9 * * *").SetTimezone(ny)},
+		{Every(time.Minute), AfterEach(time.Minute)},
+	}
+	for _, pair := range different {
+		if pair[0].SameSchedule(pair[1]) {
+			t.Errorf("expected %q and %q to be different schedules", pair[0].scheduleStr, pair[1].scheduleStr)
+		}
+	}
+
+	job := Schedule("@hourly").SetName("sync")
+	if !job.Equal(job) || !job.Equal(Schedule("@hourly").SetName("sync")) {
+		t.Errorf("expected identical jobs to be equal")
+	}
+	if job.Equal(Schedule("@hourly").SetName("other")) || job.Equal(Schedule("@hourly").SetName("sync").SetBlocking(true)) {
+		t.Errorf("expected Equal to compare names and blocking settings")
+	}
+}
+
+// TestBuild tests that Build accepts a complete job and reports the first configuration problem otherwise.
+func TestBuild(t *testing.T) {
+	noop := func(ctx context.Context) {}
+	job := Schedule("@hourly").Execute(noop).WithTimeout(time.Second).MaxRuns(3)
+	if built, err := job.Build(); err != nil || built != job {
+		t.Errorf("Build rejected a valid job: %v", err)
+	}
+
+	now := time.Now()
+	tests := []struct {
+		job  *Job
+		want string
+	}{
+		{Schedule("@hourly"), "no function"},
+		{Schedule("@hourly").Execute(noop).WithTimeout(-time.Second), "negative timeout"},
+		{Schedule("@hourly").Execute(noop).MaxRuns(-1), "negative maximum number of runs"},
+		{Schedule("@hourly").Execute(noop).WithRetry(-2, time.Second), "negative number of attempts"},
+		{Schedule("@hourly").Execute(noop).WithRetry(3, -time.Second), "negative retry backoff"},
+		{Schedule("@hourly").Execute(noop).After(now).Until(now), "is not after start time"},
+		{(&Job{Timezone: time.UTC}).Execute(noop), "no schedule"},
+	}
+	for _, test := range tests {
+		_, err := test.job.Build()
+		if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("Build returned %v, want an ErrInvalidConfig mentioning %q", err, test.want)
+		}
+	}
+}
+
+// TestConfig tests that Config reflects the job's settings.
+func TestConfig(t *testing.T) {
+	loc, _ := time.LoadLocation("America/New_York")
+	job := Schedule("0 9 * * 1-5").SetTimezone(loc).SetBlocking(true).EveryNth(2).SkipFirst().SetLimitPolicy(LimitSkip)
+
+	want := JobConfig{
+		ScheduleString: "0 9 * * 1-5",
+		Blocking:       true,
+		TimezoneName:   "America/New_York",
+		LimitPolicy:    LimitSkip,
+		EveryNth:       2,
+		SkipFirst:      true,
+	}
+	if got := job.Config(); got != want {
+		t.Errorf("Config returned %+v, want %+v", got, want)
+	}
+
+	until := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	job = Schedule("@hourly").SkipIfRunning(true).MaxRuns(3).WithTimeout(time.Minute).
+		WithJitter(time.Second).Until(until).CatchUp(true).DryRun(true)
+	want = JobConfig{
+		ScheduleString: "@hourly",
+		TimezoneName:   "UTC",
+		SkipIfRunning:  true,
+		MaxRuns:        3,
+		Timeout:        time.Minute,
+		Jitter:         time.Second,
+		Until:          until,
+		CatchUp:        true,
+		DryRun:         true,
+	}
+	if got := job.Config(); got != want {
+		t.Errorf("Config returned %+v, want %+v", got, want)
+	}
+}
+
+// TestString tests that String summarizes the job's settings and next run.
+func TestString(t *testing.T) {
+	loc, _ := time.LoadLocation("America/New_York")
+	job := Schedule("0 9 * * *").SetName("backup").SetTimezone(loc)
+	want := fmt.Sprintf(`Job{name=backup schedule="0 9 * * *" tz=America/New_York blocking=false running=false next=%s}`,
+		job.NextRun().Format(time.RFC3339Nano))
+	if got := fmt.Sprint(job); got != want {
+		t.Errorf("String returned %s, want %s", got, want)
+	}
+
+	job = Schedule("* * * * *").AfterJob(Schedule("0 0 1 1 *"), time.Second)
+	if got := job.String(); !strings.HasSuffix(got, "next=none}") {
+		t.Errorf("expected a job that never fires to report next=none, got %s", got)
+	}
+}
+
+// TestScheduleIntrospection tests ScheduleString and HasSeconds.
+func TestScheduleIntrospection(t *testing.T) {
+	tests := []struct {
+		schedule string
+		seconds  bool
+	}{
+		{"0 9 * * 1-5", false},
+		{"*/5 * * * * *", true},
+		{"500 0 * * * * *", true},
+		{"@daily", false},
+		{"@every 30s", false},
+		{"0 8 * * *; 30 0 20 * * *", true},
+		{"TZ=America/New_York 0 9 * * *", false},
+		{"CRON_TZ=UTC 30 0 9 * * *", true},
+	}
+	for _, test := range tests {
+		job := Schedule(test.schedule)
+		if got := job.ScheduleString(); got != test.schedule {
+			t.Errorf("ScheduleString returned %q, want %q", got, test.schedule)
+		}
+		if got := job.HasSeconds(); got != test.seconds {
+			t.Errorf("HasSeconds(%q) returned %t, want %t", test.schedule, got, test.seconds)
+		}
+	}
+}
+
+// TestSetData tests that attached data can be read back from a callback.
+func TestSetData(t *testing.T) {
+	type service struct{ name string }
+	svc := &service{name: "billing"}
+
+	job := Schedule("*/5 * * * * *")
+	if job.Data() != nil {
+		t.Errorf("Data is not nil before SetData")
+	}
+	var got interface{}
+	job.SetData(svc).OnSkip(func(reason string) {
+		got = job.Data()
+	})
+	job.skip(SkipWaitingOnDeps)
+	if got != svc {
+		t.Errorf("Data returned %v from a callback, want the attached value", got)
+	}
+}
+
+// TestTimezoneNext tests that schedules are evaluated in the job's timezone, not UTC.
+func TestTimezoneNext(t *testing.T) {
+	pst := time.FixedZone("PST", -8*3600)
+	ny, _ := time.LoadLocation("America/New_York")
+	// 2024-01-08 is a Monday, given as UTC so the job must do the conversion itself
+	ref := time.Date(2024, 1, 8, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		schedule string
+		loc      *time.Location
+		want     time.Time
+	}{
+		{"0 9 * * *", pst, time.Date(2024, 1, 8, 17, 0, 0, 0, time.UTC)},
+		{"0 12 * * 2", pst, time.Date(2024, 1, 9, 20, 0, 0, 0, time.UTC)},
+		{"0 20 5 * *", pst, time.Date(2024, 2, 6, 4, 0, 0, 0, time.UTC)},
+		{"0 9 * * *", time.UTC, time.Date(2024, 1, 9, 9, 0, 0, 0, time.UTC)},
+		{"30 6 * * *", ny, time.Date(2024, 1, 9, 11, 30, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		job := Schedule(test.schedule).SetTimezone(test.loc)
+		got := job.nextFireAfter(ref)
+		if !got.Equal(test.want) {
+			t.Errorf("%q in %v: next fire %v, want %v", test.schedule, test.loc, got.UTC(), test.want)
+		}
+		if got.Location() != test.loc {
+			t.Errorf("%q in %v: next fire reported in %v", test.schedule, test.loc, got.Location())
+		}
+	}
+}
+
 // TestJobExecution tests if a job increments a counter as expected.
 func TestJobExecution(t *testing.T) {
 	var counter int
@@ -87,3 +520,2350 @@ func TestJobExecution(t *testing.T) {
 		t.Errorf("Expected counter to be incremented, got %d", counter)
 	}
 }
+
+// TestWithEdgeTrigger tests that Fn only runs when the condition flips from false to true.
+func TestWithEdgeTrigger(t *testing.T) {
+	// the condition is true on ticks 2, 3 and 5, so only ticks 2 and 5 are rising edges
+	values := []bool{false, true, true, false, true, true}
+	var mutex sync.Mutex
+	var tick, runs int
+
+	job := fastJob(10 * time.Millisecond).WithEdgeTrigger(func(ctx context.Context) bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if tick >= len(values) {
+			return false
+		}
+		v := values[tick]
+		tick++
+		return v
+	}).Execute(func(ctx context.Context) {
+		mutex.Lock()
+		runs++
+		mutex.Unlock()
+	}).SetBlocking(true)
+
+	job.Start()
+	for {
+		mutex.Lock()
+		done := tick >= len(values)
+		mutex.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	job.Stop()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if runs != 2 {
+		t.Errorf("expected 2 rising edges to run Fn, got %d", runs)
+	}
+}
+
+// TestStartEContextDone tests that StartE reports an already cancelled context.
+func TestStartEContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var runs int
+	job := Schedule("* * * * * *").WithContext(ctx).Execute(func(ctx context.Context) {
+		runs++
+	})
+	if err := job.StartE(); err != ErrContextDone {
+		t.Errorf("StartE returned %v, want ErrContextDone", err)
+	}
+	if job.isRunning {
+		t.Errorf("StartE marked a job with a done context as running")
+	}
+	if runs != 0 {
+		t.Errorf("StartE ran the function without RunOnceIfDone")
+	}
+
+	job.RunOnceIfDone(true)
+	if err := job.StartE(); err != ErrContextDone {
+		t.Errorf("StartE returned %v, want ErrContextDone", err)
+	}
+	if runs != 1 {
+		t.Errorf("expected RunOnceIfDone to run the function once, got %d runs", runs)
+	}
+}
+
+// TestDependsOn tests that a job skips ticks until its dependency has run.
+func TestDependsOn(t *testing.T) {
+	dep := fastJob(time.Hour).Execute(func(ctx context.Context) {})
+
+	var mutex sync.Mutex
+	var runs int
+	var reasons []string
+	job := fastJob(10 * time.Millisecond).DependsOn(dep).SetBlocking(true).OnSkip(func(reason string) {
+		mutex.Lock()
+		reasons = append(reasons, reason)
+		mutex.Unlock()
+	}).Execute(func(ctx context.Context) {
+		mutex.Lock()
+		runs++
+		mutex.Unlock()
+	})
+
+	job.Start()
+	defer job.Stop()
+	time.Sleep(50 * time.Millisecond)
+
+	mutex.Lock()
+	if runs != 0 {
+		t.Errorf("job ran %d times before its dependency", runs)
+	}
+	if len(reasons) == 0 || reasons[0] != SkipWaitingOnDeps {
+		t.Errorf("expected skips with reason %q, got %v", SkipWaitingOnDeps, reasons)
+	}
+	mutex.Unlock()
+
+	// a single run of the dependency releases exactly one run of the job
+	dep.run(time.Now())
+	time.Sleep(50 * time.Millisecond)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if runs != 1 {
+		t.Errorf("expected 1 run after the dependency ran, got %d", runs)
+	}
+}
+
+// TestArmedFireTime tests that the armed fire time is exposed only while running.
+func TestArmedFireTime(t *testing.T) {
+	job := Schedule("0 0 1 1 *").Execute(func(ctx context.Context) {})
+	if _, ok := job.ArmedFireTime(); ok {
+		t.Errorf("ArmedFireTime reported a time before Start")
+	}
+
+	job.Start()
+	var armed time.Time
+	var ok bool
+	for i := 0; i < 100 && !ok; i++ {
+		armed, ok = job.ArmedFireTime()
+		time.Sleep(time.Millisecond)
+	}
+	if !ok {
+		t.Fatalf("ArmedFireTime reported nothing for a running job")
+	}
+	if armed.Month() != time.January || armed.Day() != 1 || !armed.After(time.Now()) {
+		t.Errorf("ArmedFireTime returned %v, want the next January 1st", armed)
+	}
+
+	job.Stop()
+	if _, ok := job.ArmedFireTime(); ok {
+		t.Errorf("ArmedFireTime reported a time after Stop")
+	}
+}
+
+// TestOnCancel tests that OnCancel fires for a run cancelled mid-execution only.
+func TestOnCancel(t *testing.T) {
+	cancelled := make(chan struct{}, 1)
+	started := make(chan struct{}, 1)
+	job := fastJob(10 * time.Millisecond).OnCancel(func() {
+		select {
+		case cancelled <- struct{}{}:
+		default:
+		}
+	}).Execute(func(ctx context.Context) {
+		select {
+		case started <- struct{}{}:
+			<-ctx.Done()
+		default:
+		}
+	})
+
+	job.Start()
+	<-started
+	select {
+	case <-cancelled:
+		t.Fatalf("OnCancel fired before the context was cancelled")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	job.Stop()
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Errorf("OnCancel did not fire after Stop cancelled an in-flight run")
+	}
+}
+
+// TestMaxCumulativeRuntime tests that a job stops itself once its runtime budget is spent.
+func TestMaxCumulativeRuntime(t *testing.T) {
+	var mutex sync.Mutex
+	var runs int
+	job := fastJob(5 * time.Millisecond).SetBlocking(true).MaxCumulativeRuntime(25 * time.Millisecond).Execute(func(ctx context.Context) {
+		mutex.Lock()
+		runs++
+		mutex.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	})
+
+	job.Start()
+	select {
+	case <-job.Ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("job did not stop after exceeding its runtime budget")
+	}
+
+	if err := job.StopCause(); err != ErrRuntimeBudgetExceeded {
+		t.Errorf("StopCause returned %v, want ErrRuntimeBudgetExceeded", err)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if runs != 3 {
+		t.Errorf("expected the third 10ms run to exceed a 25ms budget, got %d runs", runs)
+	}
+}
+
+// TestBlockingPanicRecovery tests that a panicking blocking run doesn't kill the scheduling loop.
+func TestBlockingPanicRecovery(t *testing.T) {
+	var mutex sync.Mutex
+	var runs int
+	var errs []error
+	job := fastJob(10 * time.Millisecond).SetBlocking(true).Execute(func(ctx context.Context) {
+		mutex.Lock()
+		runs++
+		first := runs == 1
+		mutex.Unlock()
+		if first {
+			panic("boom")
+		}
+	})
+	job.observer = func(fireTime time.Time, duration time.Duration, err error) {
+		mutex.Lock()
+		errs = append(errs, err)
+		mutex.Unlock()
+	}
+
+	job.Start()
+	time.Sleep(50 * time.Millisecond)
+	job.Stop()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if runs < 2 {
+		t.Fatalf("expected the job to fire again after panicking, got %d runs", runs)
+	}
+	if errs[0] == nil || errs[1] != nil {
+		t.Errorf("expected only the first run to report an error, got %v", errs)
+	}
+}
+
+// TestSkipDates tests that fire times on excluded days, in the job's timezone, move to the next allowed one.
+func TestSkipDates(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	// Tuesday 24 December 2024, 10:00 in New York
+	from := time.Date(2024, 12, 24, 15, 0, 0, 0, time.UTC)
+	job := Schedule("0 9 * * *").SetTimezone(ny).Skip(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC))
+	if got, want := job.nextFireAfter(from.Add(-3*time.Hour)), time.Date(2024, 12, 24, 9, 0, 0, 0, ny); !got.Equal(want) {
+		t.Errorf("expected the day before the holiday to fire at %v, got %v", want, got)
+	}
+	if got, want := job.nextFireAfter(from), time.Date(2024, 12, 26, 9, 0, 0, 0, ny); !got.Equal(want) {
+		t.Errorf("expected the holiday to be skipped for %v, got %v", want, got)
+	}
+
+	job.Skip(time.Date(2024, 12, 26, 0, 0, 0, 0, time.UTC))
+	if got := job.nextFireAfter(from); !got.Equal(time.Date(2024, 12, 27, 9, 0, 0, 0, ny)) {
+		t.Errorf("expected Skip to add to the excluded days, got %v", got)
+	}
+}
+
+// TestSkipFunc tests that a predicate can exclude weekends from an hourly schedule.
+func TestSkipFunc(t *testing.T) {
+	weekend := func(t time.Time) bool {
+		return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+	}
+	// Friday 15 March 2024, 22:30
+	from := time.Date(2024, 3, 15, 22, 30, 0, 0, time.UTC)
+	job := Schedule("0 * * * *").SkipFunc(weekend)
+	want := []time.Time{
+		time.Date(2024, 3, 15, 23, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 18, 1, 0, 0, 0, time.UTC),
+	}
+	at := from
+	for i, w := range want {
+		if at = job.nextFireAfter(at); !at.Equal(w) {
+			t.Errorf("fire time %d: got %v, want %v", i, at, w)
+		}
+	}
+
+	job.SkipFunc(func(time.Time) bool { return true })
+	if got := job.nextFireAfter(from); !got.IsZero() {
+		t.Errorf("expected a schedule excluding everything never to fire, got %v", got)
+	}
+	job.SkipFunc(nil)
+	if got := job.nextFireAfter(from); !got.Equal(want[0]) {
+		t.Errorf("expected SkipFunc(nil) to remove the predicate, got %v", got)
+	}
+}
+
+// TestSkipWhen tests that ticks are skipped while the predicate holds.
+func TestSkipWhen(t *testing.T) {
+	var mutex sync.Mutex
+	busy := true
+	var runs, skips int
+	job := fastJob(10 * time.Millisecond).SetBlocking(true).SkipWhen(func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return busy
+	}).OnSkip(func(reason string) {
+		if reason == SkipPredicate {
+			mutex.Lock()
+			skips++
+			mutex.Unlock()
+		}
+	}).Execute(func(ctx context.Context) {
+		mutex.Lock()
+		runs++
+		mutex.Unlock()
+	})
+
+	job.Start()
+	defer job.Stop()
+	time.Sleep(50 * time.Millisecond)
+	mutex.Lock()
+	if runs != 0 || skips == 0 {
+		t.Errorf("expected only skips while busy, got %d runs and %d skips", runs, skips)
+	}
+	busy = false
+	mutex.Unlock()
+
+	time.Sleep(50 * time.Millisecond)
+	mutex.Lock()
+	defer mutex.Unlock()
+	if runs == 0 {
+		t.Errorf("expected runs once the predicate cleared")
+	}
+}
+
+// TestNilFnWhileRunning tests that clearing Fn on a running job skips ticks instead of panicking.
+func TestNilFnWhileRunning(t *testing.T) {
+	skipped := make(chan string, 1)
+	logger := &recordingLogger{}
+	job := fastJob(10 * time.Millisecond).SetName("cleared").SetBlocking(true).WithLogger(logger).OnSkip(func(reason string) {
+		select {
+		case skipped <- reason:
+		default:
+		}
+	}).Execute(func(ctx context.Context) {})
+
+	// Execute(nil) keeps the current function
+	job.Execute(nil)
+	if job.Fn == nil {
+		t.Fatalf("Execute(nil) cleared the function")
+	}
+
+	job.Start()
+	defer job.Stop()
+	job.mutex.Lock()
+	job.Fn = nil
+	job.mutex.Unlock()
+
+	select {
+	case reason := <-skipped:
+		if reason != SkipNoFunc {
+			t.Errorf("expected reason %q, got %q", SkipNoFunc, reason)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected ticks to be skipped once Fn was cleared")
+	}
+	if !job.IsRunning() {
+		t.Errorf("the scheduling loop died after Fn was cleared")
+	}
+	logger.mutex.Lock()
+	logged := strings.Join(logger.lines, "\n")
+	logger.mutex.Unlock()
+	for _, want := range []string{"cron: nil function ignored, keeping the current one job=cleared", "cron: job has no function, tick skipped job=cleared"} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("expected %q to be logged, got:\n%s", want, logged)
+		}
+	}
+}
+
+// TestEveryNth tests that only every nth occurrence runs the function.
+func TestEveryNth(t *testing.T) {
+	job := fastJob(time.Hour).EveryNth(3).Execute(func(ctx context.Context) {})
+	var fired []int
+	for i := 1; i <= 9; i++ {
+		if job.shouldFire() {
+			fired = append(fired, i)
+		}
+	}
+	if len(fired) != 3 || fired[0] != 3 || fired[1] != 6 || fired[2] != 9 {
+		t.Errorf("expected occurrences 3, 6 and 9 to fire, got %v", fired)
+	}
+}
+
+// TestSkipFirst tests that the first scheduled time after Start is passed over.
+func TestSkipFirst(t *testing.T) {
+	job := Schedule("0 * * * *").SkipFirst().Execute(func(ctx context.Context) {})
+	first := job.nextFire()
+
+	job.Start()
+	defer job.Stop()
+	var armed time.Time
+	var ok bool
+	for i := 0; i < 100 && !ok; i++ {
+		armed, ok = job.ArmedFireTime()
+		time.Sleep(time.Millisecond)
+	}
+	if want := first.Add(time.Hour); !armed.Equal(want) {
+		t.Errorf("expected the loop to wait for %v, it armed %v", want, armed)
+	}
+}
+
+// TestStartAlignment tests that the first fire through the Start loop lands on the
+// schedule's wall-clock grid rather than an interval measured from Start.
+func TestStartAlignment(t *testing.T) {
+	type fire struct{ scheduled, actual time.Time }
+	fires := make(chan fire, 1)
+	job := Schedule("*/2 * * * * *").Execute(func(ctx context.Context) {})
+	job.observer = func(fireTime time.Time, duration time.Duration, err error) {
+		select {
+		case fires <- fire{fireTime, time.Now().Add(-duration)}:
+		default:
+		}
+	}
+
+	// start off the grid so a loop measuring from Start would be visibly misaligned
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(1300 * time.Millisecond)))
+	job.Start()
+	defer job.Stop()
+
+	select {
+	case f := <-fires:
+		if f.scheduled.Nanosecond() != 0 || f.scheduled.Second()%2 != 0 {
+			t.Errorf("first fire scheduled at %v, want an even second", f.scheduled)
+		}
+		if late := f.actual.Sub(f.scheduled); late < 0 || late > 100*time.Millisecond {
+			t.Errorf("first fire ran %v after its scheduled time", late)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("job did not fire")
+	}
+}
+
+// TestRunOnStop tests that Stop runs the function one last time with a live context.
+func TestRunOnStop(t *testing.T) {
+	var ctxErr error
+	var runs int
+	job := Schedule("0 0 1 1 *").RunOnStop().Execute(func(ctx context.Context) {
+		runs++
+		ctxErr = ctx.Err()
+	})
+
+	job.Start()
+	job.Stop()
+	if runs != 1 {
+		t.Fatalf("expected Stop to run the function once, got %d runs", runs)
+	}
+	if ctxErr != nil {
+		t.Errorf("the final run got a done context: %v", ctxErr)
+	}
+
+	// stopping a job that isn't running does nothing
+	job.Stop()
+	if runs != 1 {
+		t.Errorf("Stop on a stopped job ran the function again")
+	}
+}
+
+// TestStopAndWait tests that StopAndWait returns only once a blocking run in progress has,
+// and gives up when its context is done first.
+func TestStopAndWait(t *testing.T) {
+	started := make(chan struct{}, 1)
+	var finished atomic.Bool
+	job := fastJob(5 * time.Millisecond).SetBlocking(true).Execute(func(ctx context.Context) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		// ignores cancellation so the run outlives Stop
+		time.Sleep(50 * time.Millisecond)
+		finished.Store(true)
+	})
+	job.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := job.StopAndWait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected StopAndWait to give up at the deadline, got %v", err)
+	}
+	if job.IsRunning() {
+		t.Errorf("expected the job to be stopped even though StopAndWait gave up")
+	}
+	if err := job.StopAndWait(context.Background()); err != nil {
+		t.Fatalf("StopAndWait returned %v", err)
+	}
+	if !finished.Load() {
+		t.Errorf("expected StopAndWait to wait for the run in progress")
+	}
+}
+
+// TestAfterEach tests that fixed-delay jobs wait the delay after each run completes.
+func TestAfterEach(t *testing.T) {
+	var mutex sync.Mutex
+	var starts []time.Time
+	job := AfterEach(20 * time.Millisecond).SetBlocking(false).Execute(func(ctx context.Context) {
+		mutex.Lock()
+		starts = append(starts, time.Now())
+		mutex.Unlock()
+		time.Sleep(30 * time.Millisecond)
+	})
+
+	job.Start()
+	time.Sleep(180 * time.Millisecond)
+	job.Stop()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(starts) < 2 {
+		t.Fatalf("expected at least 2 runs, got %d", len(starts))
+	}
+	for i := 1; i < len(starts); i++ {
+		// 30ms of work plus a 20ms delay, a fixed rate would start every 20ms
+		if gap := starts[i].Sub(starts[i-1]); gap < 50*time.Millisecond {
+			t.Errorf("runs %d and %d started %v apart, want at least 50ms", i-1, i, gap)
+		}
+	}
+}
+
+// TestAfterJob tests that a job fires a delay after another job runs, and only then.
+func TestAfterJob(t *testing.T) {
+	first := Schedule("0 0 1 1 *").Execute(func(ctx context.Context) {})
+	fired := make(chan time.Time, 4)
+	second := Schedule("* * * * *").AfterJob(first, 20*time.Millisecond).Execute(func(ctx context.Context) {
+		fired <- time.Now()
+	})
+
+	second.Start()
+	defer second.Stop()
+	select {
+	case <-fired:
+		t.Fatalf("job fired before the job it follows ran")
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	for i := 0; i < 2; i++ {
+		first.run(time.Now())
+		ran := first.lastRun
+		select {
+		case at := <-fired:
+			if gap := at.Sub(ran); gap < 20*time.Millisecond {
+				t.Errorf("job fired %v after the job it follows, want at least 20ms", gap)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("job did not fire after the job it follows ran")
+		}
+	}
+}
+
+// TestMillisecondSchedule tests that a leading milliseconds field fires within the second.
+func TestMillisecondSchedule(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		schedule string
+		from     time.Time
+		want     time.Time
+	}{
+		{"*/250 * * * * * *", base.Add(100 * time.Millisecond), base.Add(250 * time.Millisecond)},
+		{"*/250 * * * * * *", base.Add(250 * time.Millisecond), base.Add(500 * time.Millisecond)},
+		{"*/250 * * * * * *", base.Add(750 * time.Millisecond), base.Add(time.Second)},
+		{"100,900 */10 * * * * *", base.Add(950 * time.Millisecond), base.Add(10*time.Second + 100*time.Millisecond)},
+		{"500-502 0 * * * * *", base.Add(501 * time.Millisecond), base.Add(502 * time.Millisecond)},
+		{"CRON_TZ=UTC 500 0 * * * * *", base, base.Add(500 * time.Millisecond)},
+	}
+	for _, test := range tests {
+		job, err := ScheduleE(test.schedule)
+		if err != nil {
+			t.Fatalf("ScheduleE(%q) failed: %v", test.schedule, err)
+		}
+		if got := job.nextFireAfter(test.from); !got.Equal(test.want) {
+			t.Errorf("%q after %v: got %v, want %v", test.schedule, test.from, got, test.want)
+		}
+	}
+
+	for _, bad := range []string{"1000 * * * * * *", "*/0 * * * * * *", "5-1 * * * * * *"} {
+		_, err := ScheduleE(bad)
+		if err == nil || !strings.Contains(err.Error(), "milliseconds field") {
+			t.Errorf("ScheduleE(%q) = %v, want a milliseconds field error", bad, err)
+		}
+	}
+
+	var runs int64
+	var mutex sync.Mutex
+	job := Schedule("*/50 * * * * * *").Execute(func(ctx context.Context) {
+		mutex.Lock()
+		runs++
+		mutex.Unlock()
+	})
+	job.Start()
+	time.Sleep(330 * time.Millisecond)
+	job.Stop()
+	mutex.Lock()
+	defer mutex.Unlock()
+	if runs < 4 {
+		t.Errorf("expected a 50ms schedule to run at least 4 times in 330ms, got %d", runs)
+	}
+}
+
+// TestNamedFields tests that month and weekday names parse in every form of expression.
+func TestNamedFields(t *testing.T) {
+	// a Wednesday
+	from := time.Date(2024, 3, 13, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		schedule string
+		want     time.Time
+	}{
+		{"0 9 * * MON-FRI", time.Date(2024, 3, 14, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * sat,sun", time.Date(2024, 3, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 JAN *", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jun-aug *", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 0 12 * DEC FRI", time.Date(2024, 12, 6, 12, 0, 30, 0, time.UTC)},
+		{"500 0 0 12 * * WED", time.Date(2024, 3, 13, 12, 0, 0, 500*int(time.Millisecond), time.UTC)},
+	}
+	for _, test := range tests {
+		job, err := ScheduleE(test.schedule)
+		if err != nil {
+			t.Errorf("ScheduleE(%q) failed: %v", test.schedule, err)
+			continue
+		}
+		if got := job.nextFireAfter(from); !got.Equal(test.want) {
+			t.Errorf("%q after %v: got %v, want %v", test.schedule, from, got, test.want)
+		}
+	}
+	if _, err := ScheduleE("0 9 * * MONDAY"); err == nil {
+		t.Errorf("ScheduleE accepted an unknown weekday name")
+	}
+}
+
+// TestEvery tests fixed-interval jobs and the schedule string they marshal with.
+func TestEvery(t *testing.T) {
+	data, err := Every(30 * time.Second).MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"schedule_str":"@every 30s"`) {
+		t.Errorf("expected schedule_str @every 30s, got %s", data)
+	}
+
+	for _, d := range []time.Duration{0, -time.Second} {
+		if _, err := EveryE(d); err == nil {
+			t.Errorf("EveryE(%v) accepted a non-positive interval", d)
+		}
+	}
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Every did not panic with a non-positive interval")
+			}
+		}()
+		Every(0)
+	}()
+
+	runs := make(chan struct{}, 10)
+	job := Every(20 * time.Millisecond).SetBlocking(true).Execute(func(ctx context.Context) {
+		runs <- struct{}{}
+	})
+	job.Start()
+	time.Sleep(110 * time.Millisecond)
+	job.Stop()
+	if n := len(runs); n < 3 {
+		t.Errorf("expected a 20ms interval to run at least 3 times in 110ms, got %d", n)
+	}
+}
+
+// TestDescriptors tests the crontab macro descriptors and that they round-trip through MarshalJSON.
+func TestDescriptors(t *testing.T) {
+	from := time.Date(2024, 3, 13, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		schedule string
+		want     time.Time
+	}{
+		{"@yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@annually", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC)},
+		{"@midnight", time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 3, 13, 11, 0, 0, 0, time.UTC)},
+		{"@every 90s", from.Add(90 * time.Second)},
+		{"@every 250ms", from.Add(250 * time.Millisecond)},
+	}
+	for _, test := range tests {
+		job, err := ScheduleE(test.schedule)
+		if err != nil {
+			t.Fatalf("ScheduleE(%q) failed: %v", test.schedule, err)
+		}
+		if got := job.nextFireAfter(from); !got.Equal(test.want) {
+			t.Errorf("%q after %v: got %v, want %v", test.schedule, from, got, test.want)
+		}
+		data, err := job.MarshalJSON()
+		if err != nil {
+			t.Fatalf("MarshalJSON failed: %v", err)
+		}
+		if !strings.Contains(string(data), `"schedule_str":"`+test.schedule+`"`) {
+			t.Errorf("expected %q to round-trip through MarshalJSON, got %s", test.schedule, data)
+		}
+	}
+
+	for _, bad := range []string{"@fortnightly", "@every", "@every soon", "@every -1s", "@every 1s 2s"} {
+		if _, err := ScheduleE(bad); err == nil {
+			t.Errorf("ScheduleE(%q) accepted an invalid descriptor", bad)
+		}
+	}
+}
+
+// TestUnmarshalJSON tests that a marshalled Job unmarshals into an equivalent one.
+func TestUnmarshalJSON(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	from := time.Date(2024, 3, 13, 10, 30, 0, 0, time.UTC)
+	originals := []*Job{
+		Schedule("*/5 * * * * *").SetBlocking(true).SetTimezone(ny),
+		Schedule("0 9 * * 1-5").SetTimezone(ny),
+		Schedule("*/250 * * * * * *"),
+		Schedule("@daily"),
+		Every(90 * time.Second),
+		AfterEach(time.Minute),
+	}
+	for _, original := range originals {
+		data, err := json.Marshal(original)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		var restored Job
+		if err := json.Unmarshal(data, &restored); err != nil {
+			t.Fatalf("Unmarshal(%s) failed: %v", data, err)
+		}
+		if restored.scheduleStr != original.scheduleStr || restored.Blocking != original.Blocking ||
+			restored.Timezone.String() != original.Timezone.String() || restored.fixedDelay != original.fixedDelay {
+			t.Errorf("round-trip of %s produced a different job: %+v", data, restored.Config())
+		}
+		if got, want := restored.nextFireAfter(from), original.nextFireAfter(from); !got.Equal(want) {
+			t.Errorf("round-trip of %q changed the next fire time from %v to %v", original.scheduleStr, want, got)
+		}
+		if restored.Fn != nil || restored.Ctx == nil || restored.Ctx.Err() != nil {
+			t.Errorf("expected no function and a live context after unmarshalling %q", original.scheduleStr)
+		}
+	}
+
+	var job Job
+	for _, bad := range []string{`{"schedule_str":"nope"}`, `{"schedule_str":"@daily","timezone":"Mars/Base"}`, `[]`} {
+		if err := json.Unmarshal([]byte(bad), &job); err == nil {
+			t.Errorf("Unmarshal(%s) accepted invalid input", bad)
+		}
+	}
+}
+
+// TestMarshalJSONKinds tests that each kind of schedule is marshalled with its kind and a canonical
+// schedule string, and round-trips through UnmarshalJSON.
+func TestMarshalJSONKinds(t *testing.T) {
+	tests := []struct {
+		job      *Job
+		kind     string
+		schedule string
+	}{
+		{Schedule("0  9 * *   1-5"), "cron", "0 9 * * 1-5"},
+		{Schedule("@daily"), "descriptor", "@daily"},
+		{Every(30 * time.Second), "interval", "@every 30s"},
+		{AfterEach(time.Minute), "after-each", "@after-each 1m0s"},
+		{Schedules("0 8 * * *", "0  20 * * *"), "union", "0 8 * * *; 0 20 * * *"},
+	}
+	for _, test := range tests {
+		data, err := json.Marshal(test.job)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		var out struct {
+			ScheduleStr string `json:"schedule_str"`
+			Kind        string `json:"kind"`
+		}
+		if err := json.Unmarshal(data, &out); err != nil {
+			t.Fatalf("Unmarshal(%s) failed: %v", data, err)
+		}
+		if out.Kind != test.kind || out.ScheduleStr != test.schedule {
+			t.Errorf("got kind %q and schedule %q, want %q and %q", out.Kind, out.ScheduleStr, test.kind, test.schedule)
+		}
+		var restored Job
+		if err := json.Unmarshal(data, &restored); err != nil {
+			t.Fatalf("Unmarshal(%s) into a Job failed: %v", data, err)
+		}
+		if got, _ := json.Marshal(&restored); string(got) != string(data) {
+			t.Errorf("round-trip changed %s into %s", data, got)
+		}
+	}
+
+	data, _ := json.Marshal(Schedule("* * * * *").AfterJob(Schedule("@daily"), time.Second))
+	var job Job
+	if err := json.Unmarshal(data, &job); err == nil {
+		t.Errorf("expected a job following another one not to be restored from %s", data)
+	}
+	if err := json.Unmarshal([]byte(`{"schedule_str":"@daily","kind":"interval","timezone":"UTC"}`), &job); err == nil {
+		t.Errorf("expected a kind that doesn't match the schedule to be rejected")
+	}
+}
+
+// TestMarshalJSONFixedHours tests that a schedule with fixed hours, which is evaluated on the wall clock,
+// is marshalled like any other and round-trips.
+func TestMarshalJSONFixedHours(t *testing.T) {
+	data, err := json.Marshal(Schedule("30 9 * * *"))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var out struct {
+		Schedule struct{ Minute, Hour uint64 } `json:"schedule"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal(%s) failed: %v", data, err)
+	}
+	if out.Schedule.Minute != 1<<30 || out.Schedule.Hour != 1<<9 {
+		t.Errorf("expected the schedule's fields in %s", data)
+	}
+	var restored Job
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatalf("Unmarshal(%s) into a Job failed: %v", data, err)
+	}
+	if got, _ := json.Marshal(&restored); string(got) != string(data) {
+		t.Errorf("round-trip changed %s into %s", data, got)
+	}
+}
+
+// TestName tests that a job's name survives JSON and Config, and that Manager.Add fills in a missing one.
+func TestName(t *testing.T) {
+	job := Schedule("@hourly").SetName("reports")
+	data, err := json.Marshal(job)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var restored Job
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatalf("Unmarshal(%s) failed: %v", data, err)
+	}
+	if restored.Name != "reports" || restored.Config().Name != "reports" {
+		t.Errorf("expected the name to round-trip through %s, got %q", data, restored.Name)
+	}
+
+	m := NewManager()
+	unnamed := Schedule("@hourly")
+	m.Add("cleanup", unnamed)
+	m.Add("other", job)
+	if unnamed.Name != "cleanup" || job.Name != "reports" {
+		t.Errorf("expected Add to name only unnamed jobs, got %q and %q", unnamed.Name, job.Name)
+	}
+}
+
+// TestNextRun tests NextRun and NextRuns against the job's schedule and timezone.
+func TestNextRun(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	job := Schedule("0 9 * * *").SetTimezone(ny)
+
+	next := job.NextRun()
+	if next.Location() != ny || next.Hour() != 9 || next.Minute() != 0 {
+		t.Errorf("expected the next run at 09:00 New York time, got %v", next)
+	}
+	if until := time.Until(next); until <= 0 || until > 25*time.Hour {
+		t.Errorf("expected the next run within a day, got %v from now", until)
+	}
+
+	runs := job.NextRuns(3)
+	if len(runs) != 3 || !runs[0].Equal(next) {
+		t.Fatalf("expected 3 runs starting at %v, got %v", next, runs)
+	}
+	for i := 1; i < len(runs); i++ {
+		if runs[i].YearDay() == runs[i-1].YearDay() || runs[i].Hour() != 9 {
+			t.Errorf("expected consecutive daily runs at 09:00, got %v after %v", runs[i], runs[i-1])
+		}
+	}
+	if runs := job.NextRuns(0); len(runs) != 0 {
+		t.Errorf("expected no runs for n=0, got %v", runs)
+	}
+
+	never := Schedule("0 0 30 2 *")
+	if !never.NextRun().IsZero() || len(never.NextRuns(5)) != 0 {
+		t.Errorf("expected a schedule that never fires to have no next run")
+	}
+}
+
+// TestNextAfterPrevBefore tests the fire times around an arbitrary reference time.
+func TestNextAfterPrevBefore(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	job := Schedule("0 9 * * 1-5").SetTimezone(ny)
+	monday := time.Date(2024, 6, 10, 12, 0, 0, 0, ny)
+
+	if next := job.NextAfter(monday); !next.Equal(time.Date(2024, 6, 11, 9, 0, 0, 0, ny)) || next.Location() != ny {
+		t.Errorf("expected Tuesday 09:00 New York time, got %v", next)
+	}
+	if prev, ok := job.PrevBefore(monday); !ok || !prev.Equal(time.Date(2024, 6, 10, 9, 0, 0, 0, ny)) || prev.Location() != ny {
+		t.Errorf("expected Monday 09:00 New York time, got %v %t", prev, ok)
+	}
+	// strictly before, and over the weekend
+	if prev, _ := job.PrevBefore(time.Date(2024, 6, 10, 9, 0, 0, 0, ny)); !prev.Equal(time.Date(2024, 6, 7, 9, 0, 0, 0, ny)) {
+		t.Errorf("expected Friday 09:00, got %v", prev)
+	}
+	job.Skip(time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC))
+	if prev, _ := job.PrevBefore(time.Date(2024, 6, 11, 8, 0, 0, 0, ny)); !prev.Equal(time.Date(2024, 6, 7, 9, 0, 0, 0, ny)) {
+		t.Errorf("expected the skipped Monday to be passed over, got %v", prev)
+	}
+
+	at := time.Date(2024, 6, 10, 12, 0, 0, 500, time.UTC)
+	if prev, _ := Schedule("* * * * * *").PrevBefore(at); !prev.Equal(at.Truncate(time.Second)) {
+		t.Errorf("expected the start of the second, got %v", prev)
+	}
+	if prev, _ := Every(90 * time.Second).PrevBefore(at); !prev.Equal(at.Add(-90 * time.Second)) {
+		t.Errorf("expected the interval before, got %v", prev)
+	}
+
+	leap := Schedule("0 0 29 2 *")
+	if prev, ok := leap.PrevBefore(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)); !ok || !prev.Equal(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected 29 February 2024, got %v %t", prev, ok)
+	}
+	if prev, ok := leap.PrevBefore(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); ok {
+		t.Errorf("expected nothing within a year, got %v", prev)
+	}
+	if next := Schedule("0 0 30 2 *").NextAfter(at); !next.IsZero() {
+		t.Errorf("expected a schedule that never fires to have no next time, got %v", next)
+	}
+}
+
+// TestSchedules tests that a Job with several schedules fires on each of their times, once per instant.
+func TestSchedules(t *testing.T) {
+	job := Schedules("0 8 * * *", "30 20 * * 5", "0 8,20 * * 1")
+	at := time.Date(2024, 6, 9, 12, 0, 0, 0, time.UTC) // a Sunday
+	want := []time.Time{
+		time.Date(2024, 6, 10, 8, 0, 0, 0, time.UTC), // both the daily and the Monday schedule
+		time.Date(2024, 6, 10, 20, 0, 0, 0, time.UTC),
+		time.Date(2024, 6, 11, 8, 0, 0, 0, time.UTC),
+		time.Date(2024, 6, 12, 8, 0, 0, 0, time.UTC),
+		time.Date(2024, 6, 13, 8, 0, 0, 0, time.UTC),
+		time.Date(2024, 6, 14, 8, 0, 0, 0, time.UTC),
+		time.Date(2024, 6, 14, 20, 30, 0, 0, time.UTC),
+	}
+	for i, w := range want {
+		if at = job.NextAfter(at); !at.Equal(w) {
+			t.Fatalf("fire time %d: expected %v, got %v", i, w, at)
+		}
+	}
+	if next := job.NextRun(); next.Hour() != 8 && next.Hour() != 20 {
+		t.Errorf("expected the earliest of the schedules' next runs, got %v", next)
+	}
+
+	if !job.SameSchedule(Schedule("0 8 * * *;30 20 * * 5; 0 8,20 * * 1")) {
+		t.Errorf("expected Schedules to build the same schedule as a semicolon separated string")
+	}
+	if never := Schedules("0 0 30 2 *", "0 0 31 4 *"); !never.NextRun().IsZero() {
+		t.Errorf("expected schedules that never fire to have no next run")
+	}
+
+	_, err := SchedulesE("0 8 * * *", "0 25 * * *")
+	if !errors.Is(err, ErrInvalidSchedule) || !strings.Contains(err.Error(), `"0 25 * * *"`) {
+		t.Errorf("expected an invalid schedule error naming the bad schedule, got %v", err)
+	}
+	if _, err := SchedulesE(); err == nil {
+		t.Errorf("expected an error without any schedule")
+	}
+}
+
+// TestLastRun tests that LastRun is zero until the job runs and then tracks the latest run.
+func TestLastRun(t *testing.T) {
+	ran := make(chan struct{}, 10)
+	job := fastJob(20 * time.Millisecond).Execute(func(ctx context.Context) {
+		ran <- struct{}{}
+	})
+	if !job.LastRun().IsZero() {
+		t.Errorf("expected a zero LastRun before the job ran, got %v", job.LastRun())
+	}
+
+	before := time.Now()
+	job.Start()
+	defer job.Stop()
+	<-ran
+	first := job.LastRun()
+	if first.Before(before) || first.After(time.Now()) {
+		t.Errorf("LastRun %v is outside the window the job ran in", first)
+	}
+	<-ran
+	if second := job.LastRun(); !second.After(first) {
+		t.Errorf("expected LastRun to advance past %v, got %v", first, second)
+	}
+}
+
+// TestRunCount tests that RunCount counts runs in both modes and is only reset by RestoreState.
+func TestRunCount(t *testing.T) {
+	for _, blocking := range []bool{true, false} {
+		ran := make(chan struct{}, 10)
+		job := fastJob(10 * time.Millisecond).SetBlocking(blocking).Execute(func(ctx context.Context) {
+			ran <- struct{}{}
+		})
+		if job.RunCount() != 0 {
+			t.Errorf("expected a new job to have run 0 times, got %d", job.RunCount())
+		}
+
+		job.Start()
+		<-ran
+		<-ran
+		job.Stop()
+		got := job.RunCount()
+		if got < 2 {
+			t.Errorf("blocking=%v: expected at least 2 runs, got %d", blocking, got)
+		}
+		if got := job.RestoreState(JobState{}).RunCount(); got != 0 {
+			t.Errorf("expected RestoreState to reset the count, got %d", got)
+		}
+	}
+}
+
+// TestIsRunning tests IsRunning before Start, after Start and after Stop.
+func TestIsRunning(t *testing.T) {
+	job := Schedule("* * * * * *").Execute(func(ctx context.Context) {})
+	if job.IsRunning() {
+		t.Errorf("expected a job that was never started not to be running")
+	}
+	job.Start()
+	if !job.IsRunning() {
+		t.Errorf("expected the job to be running after Start")
+	}
+	job.Stop()
+	if job.IsRunning() {
+		t.Errorf("expected the job not to be running after Stop")
+	}
+}
+
+// TestRestart tests that a stopped job can be started again unless its parent context is done.
+func TestRestart(t *testing.T) {
+	ran := make(chan struct{}, 10)
+	parent, cancel := context.WithCancel(context.Background())
+	job := fastJob(10 * time.Millisecond).WithContext(parent).Execute(func(ctx context.Context) {
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := job.StartE(); err != nil {
+			t.Fatalf("start %d: StartE returned %v", i, err)
+		}
+		select {
+		case <-ran:
+		case <-time.After(time.Second):
+			t.Fatalf("start %d: job did not run", i)
+		}
+		job.Stop()
+	}
+	if job.Ctx.Err() == nil {
+		t.Errorf("expected Stop to cancel the job's context")
+	}
+
+	cancel()
+	if err := job.StartE(); err != ErrContextDone {
+		t.Errorf("StartE with a done parent returned %v, want ErrContextDone", err)
+	}
+	if job.IsRunning() {
+		t.Errorf("expected a job with a done parent not to be running")
+	}
+}
+
+// TestPauseResume tests that ticks while paused are skipped and resuming restores execution.
+func TestPauseResume(t *testing.T) {
+	ran := make(chan struct{}, 10)
+	skipped := make(chan string, 10)
+	job := fastJob(30 * time.Millisecond).SetBlocking(true).Execute(func(ctx context.Context) {
+		ran <- struct{}{}
+	}).OnSkip(func(reason string) {
+		skipped <- reason
+	})
+
+	job.Start()
+	defer job.Stop()
+	<-ran
+	job.Pause()
+	select {
+	case reason := <-skipped:
+		if reason != SkipPaused {
+			t.Errorf("expected the tick to be skipped as %q, got %q", SkipPaused, reason)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected a tick to be skipped while paused")
+	}
+	job.Resume()
+	if n := len(ran); n != 0 {
+		t.Errorf("expected no runs while paused, got %d", n)
+	}
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatalf("job did not run after Resume")
+	}
+	if n := len(skipped); n != 0 {
+		t.Errorf("expected exactly one skipped tick, got %d more", n)
+	}
+}
+
+// TestTrigger tests that Trigger runs the function immediately in both modes and is recorded.
+func TestTrigger(t *testing.T) {
+	Schedule("* * * * *").Trigger()
+
+	for _, blocking := range []bool{true, false} {
+		ran := make(chan struct{}, 1)
+		job := Schedule("0 0 1 1 *").SetBlocking(blocking).Execute(func(ctx context.Context) {
+			ran <- struct{}{}
+		})
+		job.Trigger()
+		if blocking && len(ran) != 1 {
+			t.Errorf("expected a blocking Trigger to run before returning")
+		}
+		select {
+		case <-ran:
+		case <-time.After(time.Second):
+			t.Fatalf("blocking=%v: Trigger did not run the function", blocking)
+		}
+		if job.RunCount() != 1 || job.LastRun().IsZero() {
+			t.Errorf("blocking=%v: expected the triggered run to be recorded, got %d runs last at %v",
+				blocking, job.RunCount(), job.LastRun())
+		}
+		if job.IsRunning() {
+			t.Errorf("blocking=%v: expected Trigger not to start the job", blocking)
+		}
+	}
+}
+
+// TestStopLeavesNoGoroutines tests that starting and stopping many jobs, on their own loops and on a
+// shared timer, leaves no scheduling goroutines or timers behind.
+func TestStopLeavesNoGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+	noop := func(ctx context.Context) {}
+	for i := 0; i < 200; i++ {
+		job := fastJob(time.Millisecond).Execute(noop)
+		job.Start()
+		if i%2 == 0 {
+			// some stop while armed, others once their timer has fired
+			time.Sleep(2 * time.Millisecond)
+		}
+		if err := job.StopAndWait(context.Background()); err != nil {
+			t.Fatalf("StopAndWait returned %v", err)
+		}
+	}
+	s := NewScheduler().WithSharedTimer()
+	for i := 0; i < 50; i++ {
+		s.Add(fastJob(time.Millisecond).Execute(noop))
+	}
+	s.Start()
+	time.Sleep(5 * time.Millisecond)
+	s.Stop()
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if grown := runtime.NumGoroutine() - before; grown > 0 {
+		t.Errorf("expected the goroutine count to settle, %d more than before", grown)
+	}
+}
+
+// TestWaitForNextRun tests that WaitForNextRun returns once a run has happened, and not before.
+func TestWaitForNextRun(t *testing.T) {
+	var runs int64
+	job := fastJob(10 * time.Millisecond).Execute(func(ctx context.Context) {
+		atomic.AddInt64(&runs, 1)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := job.WaitForNextRun(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected WaitForNextRun to time out on a job that isn't started, got %v", err)
+	}
+
+	job.Start()
+	defer job.Stop()
+	for i := int64(1); i <= 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := job.WaitForNextRun(ctx)
+		cancel()
+		if err != nil {
+			t.Fatalf("WaitForNextRun returned %v", err)
+		}
+		if got := atomic.LoadInt64(&runs); got < i {
+			t.Fatalf("expected at least %d runs after waiting %d times, got %d", i, i, got)
+		}
+	}
+}
+
+// TestExecuteE tests that errors returned by the function reach OnError, and panics don't.
+func TestExecuteE(t *testing.T) {
+	failure := errors.New("boom")
+	var got []error
+	job := Schedule("* * * * *").SetBlocking(true).ExecuteE(func(ctx context.Context) error {
+		return failure
+	}).OnError(func(err error) {
+		got = append(got, err)
+	})
+	if job.Fn == nil {
+		t.Fatalf("expected ExecuteE to set Fn")
+	}
+	job.Trigger()
+	if len(got) != 1 || got[0] != failure {
+		t.Errorf("expected OnError to receive %v once, got %v", failure, got)
+	}
+
+	got = nil
+	job.ExecuteE(func(ctx context.Context) error { return nil }).Trigger()
+	job.ExecuteE(func(ctx context.Context) error { panic("bad") }).Trigger()
+	if len(got) != 0 {
+		t.Errorf("expected OnError to ignore successes and panics, got %v", got)
+	}
+
+	// without a handler the error is dropped
+	Schedule("* * * * *").SetBlocking(true).ExecuteE(func(ctx context.Context) error {
+		return failure
+	}).Trigger()
+
+	// Execute replaces the error-returning function
+	var plain bool
+	job.Execute(func(ctx context.Context) { plain = true }).Trigger()
+	if !plain || len(got) != 0 {
+		t.Errorf("expected Execute to replace the ExecuteE function")
+	}
+}
+
+// TestOnPanic tests that a panic on the first tick reaches OnPanic and later ticks still fire.
+func TestOnPanic(t *testing.T) {
+	var runs int64
+	ran := make(chan struct{}, 10)
+	type report struct {
+		recovered interface{}
+		stack     []byte
+	}
+	panics := make(chan report, 10)
+	job := fastJob(10 * time.Millisecond).Execute(func(ctx context.Context) {
+		if atomic.AddInt64(&runs, 1) == 1 {
+			panic("first tick")
+		}
+		ran <- struct{}{}
+	}).OnPanic(func(recovered interface{}, stack []byte) {
+		panics <- report{recovered, stack}
+	})
+
+	job.Start()
+	defer job.Stop()
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatalf("job did not keep firing after panicking")
+	}
+	select {
+	case p := <-panics:
+		if p.recovered != "first tick" || !strings.Contains(string(p.stack), "TestOnPanic") {
+			t.Errorf("expected the panic value and a stack through the job, got %v\n%s", p.recovered, p.stack)
+		}
+	default:
+		t.Fatalf("OnPanic was not called")
+	}
+	if n := len(panics); n != 0 {
+		t.Errorf("expected a single panic, got %d more", n)
+	}
+}
+
+// TestWithRetry tests that failed runs are retried with a growing backoff and only the last error is reported.
+func TestWithRetry(t *testing.T) {
+	var attempts []time.Time
+	var reported []error
+	job := Schedule("* * * * *").SetBlocking(true).ExecuteE(func(ctx context.Context) error {
+		attempts = append(attempts, time.Now())
+		return fmt.Errorf("attempt %d", len(attempts))
+	}).OnError(func(err error) {
+		reported = append(reported, err)
+	}).WithRetry(3, 10*time.Millisecond).WithBackoffMultiplier(2)
+
+	job.Trigger()
+	if len(attempts) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(attempts))
+	}
+	if first, second := attempts[1].Sub(attempts[0]), attempts[2].Sub(attempts[1]); first < 10*time.Millisecond || second < 20*time.Millisecond {
+		t.Errorf("expected backoffs of at least 10ms then 20ms, got %v then %v", first, second)
+	}
+	if len(reported) != 1 || reported[0].Error() != "attempt 3" {
+		t.Errorf("expected only the last error to be reported, got %v", reported)
+	}
+
+	// a success stops retrying
+	attempts = nil
+	job.ExecuteE(func(ctx context.Context) error {
+		attempts = append(attempts, time.Now())
+		if len(attempts) < 2 {
+			return errors.New("flaky")
+		}
+		return nil
+	}).Trigger()
+	if len(attempts) != 2 {
+		t.Errorf("expected retries to stop after a success, got %d attempts", len(attempts))
+	}
+
+	// a done context stops retrying
+	attempts = nil
+	job.WithRetry(5, time.Hour).ExecuteE(func(ctx context.Context) error {
+		attempts = append(attempts, time.Now())
+		return errors.New("down")
+	})
+	job.Start()
+	job.Stop()
+	done := make(chan struct{})
+	go func() {
+		job.Trigger()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("retries did not stop once the context was done")
+	}
+	if len(attempts) != 1 {
+		t.Errorf("expected a single attempt with a done context, got %d", len(attempts))
+	}
+}
+
+// TestWithTimeout tests that a run's context hits its deadline and is still cancelled by Stop.
+func TestWithTimeout(t *testing.T) {
+	var got error
+	Schedule("* * * * *").SetBlocking(true).WithTimeout(10 * time.Millisecond).Execute(func(ctx context.Context) {
+		<-ctx.Done()
+		got = ctx.Err()
+	}).Trigger()
+	if got != context.DeadlineExceeded {
+		t.Errorf("expected the run to see context.DeadlineExceeded, got %v", got)
+	}
+
+	errs := make(chan error, 1)
+	job := Schedule("* * * * *").WithTimeout(time.Hour).Execute(func(ctx context.Context) {
+		<-ctx.Done()
+		errs <- ctx.Err()
+	})
+	job.Start()
+	job.Trigger()
+	job.Stop()
+	select {
+	case err := <-errs:
+		if err != context.Canceled {
+			t.Errorf("expected Stop to cancel the run, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Stop did not cancel a run with a timeout")
+	}
+}
+
+// TestSkipIfRunning tests that overlapping ticks are dropped and counted, even after a panic.
+func TestSkipIfRunning(t *testing.T) {
+	var active, peak, runs int64
+	job := fastJob(5 * time.Millisecond).SkipIfRunning(true).Execute(func(ctx context.Context) {
+		n := atomic.AddInt64(&active, 1)
+		defer atomic.AddInt64(&active, -1)
+		if n > atomic.LoadInt64(&peak) {
+			atomic.StoreInt64(&peak, n)
+		}
+		if atomic.AddInt64(&runs, 1) == 1 {
+			panic("first run")
+		}
+		time.Sleep(30 * time.Millisecond)
+	})
+
+	job.Start()
+	time.Sleep(100 * time.Millisecond)
+	job.Stop()
+	if p := atomic.LoadInt64(&peak); p != 1 {
+		t.Errorf("expected non-blocking runs to never overlap, peak was %d", p)
+	}
+	if r := atomic.LoadInt64(&runs); r < 2 {
+		t.Errorf("expected the job to run again after panicking, got %d runs", r)
+	}
+	if job.SkippedCount() == 0 {
+		t.Errorf("expected some ticks to be skipped while a run was in progress")
+	}
+}
+
+// TestDelayIfRunning tests that a run due during another waits for it and then starts at once.
+func TestDelayIfRunning(t *testing.T) {
+	var active, peak int64
+	var mutex sync.Mutex
+	var starts, ends []time.Time
+	job := Schedule("* * * * *").DelayIfRunning(true).Execute(func(ctx context.Context) {
+		if n := atomic.AddInt64(&active, 1); n > atomic.LoadInt64(&peak) {
+			atomic.StoreInt64(&peak, n)
+		}
+		mutex.Lock()
+		starts = append(starts, time.Now())
+		mutex.Unlock()
+		time.Sleep(30 * time.Millisecond)
+		mutex.Lock()
+		ends = append(ends, time.Now())
+		mutex.Unlock()
+		atomic.AddInt64(&active, -1)
+	})
+
+	job.tick(time.Now(), true)
+	time.Sleep(5 * time.Millisecond)
+	job.tick(time.Now(), true)
+	if err := job.inflight.wait(context.Background()); err != nil {
+		t.Fatalf("waiting for runs failed: %v", err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(starts) != 2 {
+		t.Fatalf("expected both ticks to run, got %d runs", len(starts))
+	}
+	if p := atomic.LoadInt64(&peak); p != 1 {
+		t.Errorf("expected runs to never overlap, peak was %d", p)
+	}
+	if gap := starts[1].Sub(ends[0]); gap < 0 || gap > 20*time.Millisecond {
+		t.Errorf("expected the delayed run to start right after the first ended, started %v later", gap)
+	}
+
+	// a waiting run gives up once the job is stopped
+	release := make(chan struct{})
+	var late int64
+	stuck := Schedule("* * * * *").DelayIfRunning(true).Execute(func(ctx context.Context) {
+		if atomic.AddInt64(&late, 1) == 1 {
+			<-release
+		}
+	})
+	stuck.Start()
+	stuck.tick(time.Now(), true)
+	time.Sleep(5 * time.Millisecond)
+	stuck.tick(time.Now(), true)
+	stuck.Stop()
+	time.Sleep(5 * time.Millisecond)
+	close(release)
+	stuck.inflight.wait(context.Background())
+	if n := atomic.LoadInt64(&late); n != 1 {
+		t.Errorf("expected the waiting run to give up after Stop, got %d runs", n)
+	}
+}
+
+// TestMaxRuns tests that a job stops itself after the configured number of runs.
+func TestMaxRuns(t *testing.T) {
+	var runs int64
+	job := fastJob(5 * time.Millisecond).MaxRuns(3).Execute(func(ctx context.Context) {
+		atomic.AddInt64(&runs, 1)
+		time.Sleep(10 * time.Millisecond)
+	})
+
+	job.Start()
+	deadline := time.Now().Add(time.Second)
+	for job.IsRunning() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if job.IsRunning() {
+		t.Fatalf("expected the job to stop itself after 3 runs")
+	}
+	job.inflight.wait(context.Background())
+	if n := atomic.LoadInt64(&runs); n != 3 || job.RunCount() != 3 {
+		t.Errorf("expected exactly 3 runs, got %d (RunCount %d)", n, job.RunCount())
+	}
+	if job.StopCause() != ErrMaxRunsReached {
+		t.Errorf("expected StopCause ErrMaxRunsReached, got %v", job.StopCause())
+	}
+	if err := job.StartE(); err != ErrMaxRunsReached || job.IsRunning() {
+		t.Errorf("expected StartE to refuse a job that used up its runs, got %v", err)
+	}
+	job.MaxRuns(4)
+	if err := job.StartE(); err != nil {
+		t.Errorf("expected StartE to succeed once MaxRuns is raised, got %v", err)
+	}
+	job.Stop()
+}
+
+// TestAfter tests that a job started before its start time only runs once that time has passed,
+// and that stopping it ends the wait.
+func TestAfter(t *testing.T) {
+	var first atomic.Value
+	start := time.Now().Add(50 * time.Millisecond)
+	job := fastJob(10 * time.Millisecond).After(start).RunOnStart(true).Execute(func(ctx context.Context) {
+		first.CompareAndSwap(nil, time.Now())
+	})
+	if next := job.NextRun(); next.Before(start) {
+		t.Errorf("expected NextRun to be after the start time, got %v", next)
+	}
+	job.Start()
+	time.Sleep(100 * time.Millisecond)
+	job.Stop()
+	ran, ok := first.Load().(time.Time)
+	if !ok {
+		t.Fatalf("expected the job to run once its start time passed")
+	}
+	if ran.Before(start) {
+		t.Errorf("the job ran at %v, before its start time %v", ran, start)
+	}
+
+	stopped := make(chan struct{}, 1)
+	job = fastJob(10 * time.Millisecond).After(time.Now().Add(time.Hour)).OnStop(func() {
+		stopped <- struct{}{}
+	}).Execute(func(ctx context.Context) {
+		t.Errorf("the job ran before its start time")
+	})
+	job.Start()
+	job.Stop()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Errorf("expected Stop to end the wait")
+	}
+}
+
+// TestUntil tests that a job stops itself instead of firing at or after its end time,
+// and that MaxRuns still applies when it is reached first.
+func TestUntil(t *testing.T) {
+	end := time.Now().Add(55 * time.Millisecond)
+	job := fastJob(10 * time.Millisecond).Until(end).Execute(func(ctx context.Context) {})
+	if !job.EndTime().Equal(end) {
+		t.Errorf("EndTime returned %v, want %v", job.EndTime(), end)
+	}
+	job.Start()
+	deadline := time.Now().Add(time.Second)
+	for job.IsRunning() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if job.IsRunning() {
+		t.Fatalf("expected the job to stop itself at its end time")
+	}
+	if job.StopCause() != ErrEndTimeReached {
+		t.Errorf("expected StopCause ErrEndTimeReached, got %v", job.StopCause())
+	}
+	if n := job.RunCount(); n == 0 || n > 5 {
+		t.Errorf("expected between 1 and 5 runs before the end time, got %d", n)
+	}
+	if job.LastRun().After(end) {
+		t.Errorf("the job ran at %v, after its end time %v", job.LastRun(), end)
+	}
+
+	// an end time already past stops the job straight away, after it started
+	var probe []string
+	job = fastJob(10 * time.Millisecond).Until(time.Now().Add(-time.Minute)).Execute(func(ctx context.Context) {}).
+		OnStart(func() { probe = append(probe, "start") }).
+		OnStop(func() { probe = append(probe, "stop") })
+	if err := job.StartE(); err != ErrEndTimeReached {
+		t.Errorf("expected StartE to return ErrEndTimeReached, got %v", err)
+	}
+	if got := strings.Join(probe, " "); got != "start stop" {
+		t.Errorf("expected OnStart before OnStop, got %q", got)
+	}
+	if job.IsRunning() {
+		t.Errorf("expected the job to be stopped")
+	}
+	if err := job.StopAndWait(context.Background()); err != nil {
+		t.Errorf("StopAndWait returned %v", err)
+	}
+
+	job = fastJob(5 * time.Millisecond).Until(time.Now().Add(time.Hour)).MaxRuns(2).Execute(func(ctx context.Context) {})
+	job.Start()
+	deadline = time.Now().Add(time.Second)
+	for job.IsRunning() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if job.StopCause() != ErrMaxRunsReached {
+		t.Errorf("expected MaxRuns to stop the job first, got %v", job.StopCause())
+	}
+}
+
+// TestCatchUp tests that a job restored with a last run before missed fire times runs once on Start.
+func TestCatchUp(t *testing.T) {
+	var runs int64
+	count := func(ctx context.Context) { atomic.AddInt64(&runs, 1) }
+	state := JobState{LastRun: time.Now().Add(-3 * time.Hour), RunCount: 5}
+
+	job := Schedule("0 * * * *").CatchUp(true).RestoreState(state).Execute(count)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	job.Start()
+	if err := job.WaitForNextRun(ctx); err != nil {
+		t.Fatalf("expected missed fire times to be caught up on Start: %v", err)
+	}
+	_ = job.StopAndWait(ctx)
+	if n := atomic.LoadInt64(&runs); n != 1 {
+		t.Errorf("expected several missed hours to be caught up with a single run, got %d", n)
+	}
+	if job.RunCount() != 6 {
+		t.Errorf("expected the catch-up run to be counted, got %d", job.RunCount())
+	}
+
+	// nothing was missed since that run, so there is nothing to catch up on
+	jobs := []*Job{
+		job,
+		Schedule("0 * * * *").RestoreState(state).Execute(count),
+		Schedule("0 * * * *").CatchUp(true).Execute(count),
+	}
+	for _, j := range jobs {
+		j.Start()
+	}
+	time.Sleep(20 * time.Millisecond)
+	for _, j := range jobs {
+		_ = j.StopAndWait(ctx)
+	}
+	if n := atomic.LoadInt64(&runs); n != 1 {
+		t.Errorf("expected no catch-up when nothing was missed, when disabled or without a last run, got %d runs", n)
+	}
+}
+
+// TestRunOnStart tests that Start runs the function at once and that Stop during that run ends the job.
+func TestRunOnStart(t *testing.T) {
+	ran := make(chan struct{}, 1)
+	job := Schedule("0 0 1 1 *").RunOnStart(true).Execute(func(ctx context.Context) {
+		ran <- struct{}{}
+	})
+	job.Start()
+	defer job.Stop()
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatalf("RunOnStart did not run the function on Start")
+	}
+	if job.RunCount() != 1 {
+		t.Errorf("expected the immediate run to be counted, got %d", job.RunCount())
+	}
+
+	var runs int64
+	var stopping *Job
+	stopping = fastJob(5 * time.Millisecond).SetBlocking(true).RunOnStart(true).Execute(func(ctx context.Context) {
+		if atomic.AddInt64(&runs, 1) == 1 {
+			stopping.Stop()
+		}
+	})
+	stopping.Start()
+	time.Sleep(30 * time.Millisecond)
+	if n := atomic.LoadInt64(&runs); n != 1 {
+		t.Errorf("expected no scheduled runs after Stop during the immediate run, got %d runs", n)
+	}
+	if _, armed := stopping.ArmedFireTime(); armed || stopping.IsRunning() {
+		t.Errorf("expected the job to be stopped without arming its timer")
+	}
+}
+
+// TestWithJitter tests that jitter is drawn per tick from the job's source and never skips an occurrence.
+func TestWithJitter(t *testing.T) {
+	job := Schedule("0 * * * *").WithJitter(10 * time.Second).WithJitterSource(rand.NewSource(1))
+	expected := rand.New(rand.NewSource(1))
+	for i := 0; i < 3; i++ {
+		scheduled := job.nextFire()
+		want := scheduled.Add(time.Duration(expected.Int63n(int64(10*time.Second) + 1)))
+		if got, _, _ := job.arm(time.Time{}); !got.Equal(want) {
+			t.Errorf("tick %d: armed %v, want %v", i, got, want)
+		}
+	}
+
+	// a jitter longer than the interval stays short of the following second
+	fast := Schedule("* * * * * *").WithJitter(time.Minute)
+	for i := 0; i < 20; i++ {
+		scheduled := fast.nextFire()
+		if got, _, _ := fast.arm(time.Time{}); got.Before(scheduled) || !got.Before(scheduled.Add(time.Second)) {
+			t.Fatalf("armed %v, want within a second of %v", got, scheduled)
+		}
+	}
+}
+
+// TestValidate tests that Validate accepts what Schedule accepts and reports the same errors as ScheduleE.
+func TestValidate(t *testing.T) {
+	for _, valid := range []string{"*/5 * * * *", "0 */5 * * * *", "*/250 * * * * * *", "@daily", "@every 90s"} {
+		if err := Validate(valid); err != nil {
+			t.Errorf("Validate(%q) rejected a valid schedule: %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"", "60 * * * * *", "0 0 30 2", "@fortnightly"} {
+		err := Validate(invalid)
+		_, want := ScheduleE(invalid)
+		if err == nil || want == nil || err.Error() != want.Error() {
+			t.Errorf("Validate(%q) returned %v, want %v", invalid, err, want)
+		}
+	}
+}
+
+// TestParserSelection tests that every supported form parses through the same path, whatever the whitespace.
+func TestParserSelection(t *testing.T) {
+	from := time.Date(2024, 3, 13, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		schedule string
+		want     time.Time
+	}{
+		{"*/5 * * * *", time.Date(2024, 3, 13, 10, 35, 0, 0, time.UTC)},
+		{"  */5\t* *  * *\n", time.Date(2024, 3, 13, 10, 35, 0, 0, time.UTC)},
+		{"30 */5 * * * *", time.Date(2024, 3, 13, 10, 30, 30, 0, time.UTC)},
+		{" 30 */5 * * * * ", time.Date(2024, 3, 13, 10, 30, 30, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 3, 13, 11, 0, 0, 0, time.UTC)},
+		{" @hourly ", time.Date(2024, 3, 13, 11, 0, 0, 0, time.UTC)},
+		{"@every 10s", from.Add(10 * time.Second)},
+		{"@every  10s ", from.Add(10 * time.Second)},
+	}
+	for _, test := range tests {
+		job, err := ScheduleE(test.schedule)
+		if err != nil {
+			t.Errorf("ScheduleE(%q) failed: %v", test.schedule, err)
+			continue
+		}
+		if got := job.nextFireAfter(from); !got.Equal(test.want) {
+			t.Errorf("%q after %v: got %v, want %v", test.schedule, from, got, test.want)
+		}
+	}
+}
+
+// TestDaylightSaving tests fire times around the America/New_York transitions of 2024.
+func TestDaylightSaving(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	at := func(month time.Month, day, hour, min int, zone string) string {
+		return fmt.Sprintf("%02d-%02d %02d:%02d %s", month, day, hour, min, zone)
+	}
+	tests := []struct {
+		name     string
+		schedule string
+		from     time.Time
+		want     []string
+	}{
+		{
+			"daily time skipped by spring forward fires after the jump",
+			"30 2 * * *", time.Date(2024, 3, 9, 12, 0, 0, 0, ny),
+			[]string{at(3, 10, 3, 30, "EDT"), at(3, 11, 2, 30, "EDT")},
+		},
+		{
+			"milliseconds form follows the wall clock too",
+			"0 0 30 2 * * *", time.Date(2024, 3, 9, 12, 0, 0, 0, ny),
+			[]string{at(3, 10, 3, 30, "EDT"), at(3, 11, 2, 30, "EDT")},
+		},
+		{
+			"daily time repeated by fall back fires once",
+			"30 1 * * *", time.Date(2024, 11, 2, 12, 0, 0, 0, ny),
+			[]string{at(11, 3, 1, 30, "EDT"), at(11, 4, 1, 30, "EST")},
+		},
+		{
+			"daily time outside the transition is unaffected",
+			"0 9 * * *", time.Date(2024, 3, 9, 12, 0, 0, 0, ny),
+			[]string{at(3, 10, 9, 0, "EDT"), at(3, 11, 9, 0, "EDT")},
+		},
+		{
+			"hourly job skips the hour that doesn't exist",
+			"0 * * * *", time.Date(2024, 3, 10, 0, 30, 0, 0, ny),
+			[]string{at(3, 10, 1, 0, "EST"), at(3, 10, 3, 0, "EDT"), at(3, 10, 4, 0, "EDT")},
+		},
+		{
+			"hourly job keeps firing every elapsed hour through fall back",
+			"0 * * * *", time.Date(2024, 11, 3, 0, 30, 0, 0, ny),
+			[]string{at(11, 3, 1, 0, "EDT"), at(11, 3, 1, 0, "EST"), at(11, 3, 2, 0, "EST")},
+		},
+		{
+			"fixed hour with every minute runs through the repeated hour once",
+			"58-59 1 * * *", time.Date(2024, 11, 3, 1, 57, 0, 0, ny),
+			[]string{at(11, 3, 1, 58, "EDT"), at(11, 3, 1, 59, "EDT"), at(11, 4, 1, 58, "EST")},
+		},
+	}
+	for _, test := range tests {
+		job := Schedule(test.schedule).SetTimezone(ny)
+		next := test.from
+		var got []string
+		for range test.want {
+			next = job.nextFireAfter(next)
+			got = append(got, next.Format("01-02 15:04 MST"))
+		}
+		if strings.Join(got, ", ") != strings.Join(test.want, ", ") {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	// starting inside the repeated hour, after its first pass, doesn't fire it again
+	job := Schedule("45 1 * * *").SetTimezone(ny)
+	secondPass := time.Date(2024, 11, 3, 6, 30, 0, 0, time.UTC) // 01:30 EST
+	if got := job.nextFireAfter(secondPass).Format("01-02 15:04 MST"); got != at(11, 4, 1, 45, "EST") {
+		t.Errorf("from the repeated hour: got %v, want the next day", got)
+	}
+}
+
+// TestOnStartOnStop tests that the lifecycle callbacks run once per start and stop, however the job stops.
+func TestOnStartOnStop(t *testing.T) {
+	var starts, stops int64
+	parent, cancel := context.WithCancel(context.Background())
+	job := Schedule("* * * * *").WithContext(parent).Execute(func(ctx context.Context) {}).
+		OnStart(func() { atomic.AddInt64(&starts, 1) }).
+		OnStop(func() { atomic.AddInt64(&stops, 1) })
+
+	job.Start()
+	if n := atomic.LoadInt64(&starts); n != 1 {
+		t.Errorf("expected OnStart to run when the job started, got %d calls", n)
+	}
+	job.Stop()
+	job.Stop()
+	if n := atomic.LoadInt64(&stops); n != 1 {
+		t.Errorf("expected OnStop to run once for repeated Stop calls, got %d calls", n)
+	}
+
+	job.Start()
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt64(&stops) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	job.Stop()
+	if s, e := atomic.LoadInt64(&starts), atomic.LoadInt64(&stops); s != 2 || e != 2 {
+		t.Errorf("expected 2 starts and 2 stops after the context was cancelled, got %d and %d", s, e)
+	}
+}
+
+// TestRunHooks tests that the before and after hooks wrap each run with its real duration.
+func TestRunHooks(t *testing.T) {
+	type key struct{}
+	var order []string
+	job := Schedule("* * * * *").SetBlocking(true).
+		WithContext(context.WithValue(context.Background(), key{}, "tagged")).
+		OnBeforeRun(func(ctx context.Context) {
+			order = append(order, "before:"+ctx.Value(key{}).(string))
+			time.Sleep(50 * time.Millisecond)
+		}).
+		Execute(func(ctx context.Context) {
+			order = append(order, "run")
+			time.Sleep(10 * time.Millisecond)
+		}).
+		OnAfterRun(func(ctx context.Context, duration time.Duration) {
+			order = append(order, "after")
+			if duration < 10*time.Millisecond || duration >= 50*time.Millisecond {
+				t.Errorf("expected the duration of the function alone, got %v", duration)
+			}
+		})
+
+	job.Trigger()
+	if got := strings.Join(order, ","); got != "before:tagged,run,after" {
+		t.Errorf("expected hooks around the run, got %s", got)
+	}
+
+	done := make(chan struct{})
+	async := Schedule("* * * * *").Execute(func(ctx context.Context) {
+		time.Sleep(10 * time.Millisecond)
+	}).OnAfterRun(func(ctx context.Context, duration time.Duration) {
+		if duration < 10*time.Millisecond {
+			t.Errorf("expected a non-blocking run's real duration, got %v", duration)
+		}
+		close(done)
+	})
+	async.Trigger()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("OnAfterRun was not called for a non-blocking run")
+	}
+}
+
+// recordingLogger keeps the message of every line logged to it, along with its "job" attribute.
+type recordingLogger struct {
+	mutex sync.Mutex
+	lines []string
+}
+
+func (l *recordingLogger) record(msg string, args []interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for i := 0; i+1 < len(args); i += 2 {
+		if args[i] == "job" {
+			msg += fmt.Sprintf(" job=%v", args[i+1])
+		}
+	}
+	l.lines = append(l.lines, msg)
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) { l.record(msg, args) }
+func (l *recordingLogger) Info(msg string, args ...interface{})  { l.record(msg, args) }
+func (l *recordingLogger) Error(msg string, args ...interface{}) { l.record(msg, args) }
+
+// TestWithLogger tests that a job logs its start, runs, failures, panics and stop, tagged with its name.
+func TestWithLogger(t *testing.T) {
+	logger := &recordingLogger{}
+	var runs int64
+	job := fastJob(10 * time.Millisecond).SetName("flaky").SetBlocking(true).WithLogger(logger).ExecuteE(func(ctx context.Context) error {
+		if atomic.AddInt64(&runs, 1) == 1 {
+			return errors.New("boom")
+		}
+		panic("bang")
+	})
+	job.Start()
+	time.Sleep(35 * time.Millisecond)
+	job.Stop()
+
+	logger.mutex.Lock()
+	logged := strings.Join(logger.lines, "\n")
+	logger.mutex.Unlock()
+	for _, want := range []string{
+		"cron: job started job=flaky",
+		"cron: job running job=flaky",
+		"cron: job failed job=flaky",
+		"cron: job panicked job=flaky",
+		"cron: job stopped job=flaky",
+	} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("expected %q to be logged, got:\n%s", want, logged)
+		}
+	}
+
+	// without a logger nothing changes
+	Schedule("* * * * *").Execute(func(ctx context.Context) {}).WithLogger(nil).Trigger()
+}
+
+// recordingMetrics counts what a job reports to its Metrics.
+type recordingMetrics struct {
+	mutex     sync.Mutex
+	counts    map[string]int
+	durations []time.Duration
+}
+
+func (m *recordingMetrics) inc(kind, name string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.counts == nil {
+		m.counts = make(map[string]int)
+	}
+	m.counts[kind+" "+name]++
+}
+
+func (m *recordingMetrics) IncRun(name string)   { m.inc("run", name) }
+func (m *recordingMetrics) IncError(name string) { m.inc("error", name) }
+func (m *recordingMetrics) IncSkip(name string)  { m.inc("skip", name) }
+func (m *recordingMetrics) ObserveDuration(name string, d time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.durations = append(m.durations, d)
+}
+
+// TestWithMetrics tests that runs, errors, durations and skips are reported under the job's name.
+func TestWithMetrics(t *testing.T) {
+	metrics := &recordingMetrics{}
+	var runs int64
+	job := Schedule("* * * * *").SetName("sync").SetBlocking(true).WithMetrics(metrics).ExecuteE(func(ctx context.Context) error {
+		time.Sleep(time.Millisecond)
+		if atomic.AddInt64(&runs, 1) == 1 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	job.Trigger()
+	job.Trigger()
+	job.Pause()
+	job.tick(time.Now(), true)
+
+	want := map[string]int{"run sync": 2, "error sync": 1, "skip sync": 1}
+	if fmt.Sprint(metrics.counts) != fmt.Sprint(want) {
+		t.Errorf("got counts %v, want %v", metrics.counts, want)
+	}
+	if len(metrics.durations) != 2 || metrics.durations[0] < time.Millisecond {
+		t.Errorf("expected the duration of both runs to be observed, got %v", metrics.durations)
+	}
+
+	// without metrics nothing changes
+	Schedule("* * * * *").Execute(func(ctx context.Context) {}).WithMetrics(nil).Trigger()
+}
+
+// recordingTracer records the spans it starts.
+type recordingTracer struct {
+	spans []*recordingSpan
+}
+
+type recordingSpan struct {
+	name  string
+	errs  []error
+	ended bool
+}
+
+type spanKey struct{}
+
+func (tr *recordingTracer) Start(ctx context.Context, spanName string) (context.Context, Span) {
+	span := &recordingSpan{name: spanName}
+	tr.spans = append(tr.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (s *recordingSpan) RecordError(err error) { s.errs = append(s.errs, err) }
+func (s *recordingSpan) End()                  { s.ended = true }
+
+// TestWithTracer tests that each run gets its own span, carried by the function's context and recording its error.
+func TestWithTracer(t *testing.T) {
+	tracer := &recordingTracer{}
+	failure := errors.New("boom")
+	var seen []interface{}
+	job := Schedule("* * * * *").SetName("export").SetBlocking(true).WithTracer(tracer).ExecuteE(func(ctx context.Context) error {
+		seen = append(seen, ctx.Value(spanKey{}))
+		if len(seen) == 1 {
+			return failure
+		}
+		return nil
+	})
+	job.Trigger()
+	job.Trigger()
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("expected a span per run, got %d", len(tracer.spans))
+	}
+	for i, span := range tracer.spans {
+		if span.name != "export" || !span.ended || seen[i] != span {
+			t.Errorf("span %d: expected an ended span named after the job, carried by the run's context, got %+v", i, span)
+		}
+	}
+	if errs := tracer.spans[0].errs; len(errs) != 1 || errs[0] != failure {
+		t.Errorf("expected the first span to record the run's error, got %v", errs)
+	}
+	if errs := tracer.spans[1].errs; len(errs) != 0 {
+		t.Errorf("expected no error on a successful run's span, got %v", errs)
+	}
+
+	Schedule("@daily").SetBlocking(true).WithTracer(tracer).Execute(func(ctx context.Context) {}).Trigger()
+	if got := tracer.spans[2].name; got != "@daily" {
+		t.Errorf("expected a job without a name to name its span after its schedule, got %q", got)
+	}
+}
+
+// TestClone tests that a clone copies the configuration but runs and stops independently.
+func TestClone(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	var runs int64
+	original := fastJob(10 * time.Millisecond).SetName("copy").SetBlocking(true).SetTimezone(ny).
+		Execute(func(ctx context.Context) { atomic.AddInt64(&runs, 1) })
+	original.Start()
+	defer original.Stop()
+
+	clone := original.Clone()
+	if clone.IsRunning() {
+		t.Errorf("expected the clone of a running job to be stopped")
+	}
+	if got, want := clone.Config(), original.Config(); got != want {
+		t.Errorf("expected the clone's configuration %+v, got %+v", want, got)
+	}
+	if clone.RunCount() != 0 {
+		t.Errorf("expected the clone to start with no runs, got %d", clone.RunCount())
+	}
+
+	clone.Start()
+	clone.Stop()
+	if !original.IsRunning() || original.Ctx.Err() != nil {
+		t.Errorf("stopping the clone stopped the original")
+	}
+
+	clone.Start()
+	original.Stop()
+	if !clone.IsRunning() || clone.Ctx.Err() != nil {
+		t.Errorf("stopping the original stopped the clone")
+	}
+	before := atomic.LoadInt64(&runs)
+	time.Sleep(35 * time.Millisecond)
+	clone.Stop()
+	if atomic.LoadInt64(&runs) <= before || clone.RunCount() == 0 {
+		t.Errorf("expected the clone to keep running the shared function")
+	}
+}
+
+// TestConcurrentReconfiguration tests that a running job can be reconfigured from several goroutines,
+// and is meant to be run with -race.
+func TestConcurrentReconfiguration(t *testing.T) {
+	var runs int64
+	count := func(ctx context.Context) { atomic.AddInt64(&runs, 1) }
+	job := fastJob(time.Millisecond).Execute(count)
+	job.Start()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				switch (i + n) % 5 {
+				case 0:
+					job.WithContext(context.Background())
+				case 1:
+					job.SetTimezone(time.UTC).SetBlocking(n%2 == 0)
+				case 2:
+					job.Execute(count)
+				case 3:
+					_, _ = job.NextRun(), job.Config()
+				case 4:
+					job.Stop()
+				}
+				job.Start()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if !job.IsRunning() {
+		t.Fatalf("expected the job to be running after the final Start")
+	}
+	before := atomic.LoadInt64(&runs)
+	time.Sleep(20 * time.Millisecond)
+	job.Stop()
+	if atomic.LoadInt64(&runs) <= before {
+		t.Errorf("expected the job to keep running after being reconfigured")
+	}
+}
+
+// TestFirstRunAt tests that Start settles the first fire time, and that AlignFirstRun puts intervals on the clock's grid.
+func TestFirstRunAt(t *testing.T) {
+	noop := func(ctx context.Context) {}
+	daily := Schedule("0 9 * * *").Execute(noop)
+	if !daily.FirstRunAt().IsZero() {
+		t.Errorf("expected no first run before Start")
+	}
+	daily.Start()
+	defer daily.Stop()
+	if first := daily.FirstRunAt(); !first.Equal(daily.NextRun()) {
+		t.Errorf("expected the first run at the next scheduled time %v, got %v", daily.NextRun(), first)
+	}
+	if at, ok := daily.ArmedFireTime(); !ok || !at.Equal(daily.FirstRunAt()) {
+		t.Errorf("expected the loop to wait for the first run, armed %v", at)
+	}
+
+	before := time.Now()
+	immediate := Schedule("0 9 * * *").RunOnStart(true).Execute(noop)
+	immediate.Start()
+	defer immediate.Stop()
+	if first := immediate.FirstRunAt(); first.Before(before) || first.After(time.Now()) {
+		t.Errorf("expected RunOnStart to make the first run immediate, got %v", first)
+	}
+
+	hourly := Every(time.Hour).Execute(noop)
+	hourly.Start()
+	defer hourly.Stop()
+	if first := hourly.FirstRunAt(); first.Sub(before) < time.Hour {
+		t.Errorf("expected an interval to be counted from Start, got %v", first)
+	}
+
+	aligned := Every(time.Hour).AlignFirstRun(true).SetTimezone(time.UTC).Execute(noop)
+	aligned.Start()
+	defer aligned.Stop()
+	first := aligned.FirstRunAt()
+	if first.Minute() != 0 || first.Second() != 0 || first.Nanosecond() != 0 || !first.After(before) || first.Sub(before) > time.Hour {
+		t.Errorf("expected the first run at the top of the next hour, got %v", first)
+	}
+	// switching between an interval and a cron expression while aligned mustn't trip up the scheduling loop
+	for i := 0; i < 200; i++ {
+		scheduleStr := "@every 1h"
+		if i%2 == 0 {
+			scheduleStr = "* * * * *"
+		}
+		if err := aligned.Reset(scheduleStr); err != nil {
+			t.Fatalf("Reset(%q) failed: %v", scheduleStr, err)
+		}
+	}
+
+	if got := alignDown(time.Date(2024, 6, 10, 10, 7, 17, 0, time.UTC), 15*time.Minute, time.UTC); !got.Equal(time.Date(2024, 6, 10, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("alignDown returned %v", got)
+	}
+	// a whole-hour interval follows the local clock, not UTC, where the offset isn't whole hours
+	kolkata, _ := time.LoadLocation("Asia/Kolkata")
+	if got := alignDown(time.Date(2024, 6, 10, 10, 7, 0, 0, kolkata), time.Hour, kolkata); !got.Equal(time.Date(2024, 6, 10, 10, 0, 0, 0, kolkata)) {
+		t.Errorf("alignDown returned %v in Kolkata", got)
+	}
+}
+
+// TestAddFunc tests that added functions run in order at every tick and that Execute replaces them.
+func TestAddFunc(t *testing.T) {
+	var steps []string
+	step := func(name string) func(ctx context.Context) {
+		return func(ctx context.Context) { steps = append(steps, name) }
+	}
+	job := Schedule("* * * * *").SetBlocking(true).AddFunc(step("fetch")).AddFunc(step("transform")).AddFunc(step("load"))
+	job.Trigger()
+	if got := strings.Join(steps, ","); got != "fetch,transform,load" {
+		t.Errorf("expected the steps in order, got %s", got)
+	}
+	if job.RunCount() != 1 {
+		t.Errorf("expected the steps to make up a single run, got %d", job.RunCount())
+	}
+
+	// a failing step doesn't stop the others, and its error is reported once
+	var errs []error
+	steps = nil
+	job.ExecuteE(func(ctx context.Context) error { return errors.New("boom") }).AddFunc(step("cleanup")).OnError(func(err error) {
+		errs = append(errs, err)
+	})
+	job.Trigger()
+	if len(steps) != 1 || len(errs) != 1 {
+		t.Errorf("expected cleanup to run after the failure and one error, got steps %v and errors %v", steps, errs)
+	}
+
+	steps = nil
+	job.Execute(step("only"))
+	job.Trigger()
+	if got := strings.Join(steps, ","); got != "only" {
+		t.Errorf("expected Execute to replace the added functions, got %s", got)
+	}
+}
+
+// TestReset tests that a running job picks up a new schedule without restarting, and that an invalid one is refused.
+func TestReset(t *testing.T) {
+	job := Schedule("* * * * * *").Execute(func(ctx context.Context) {})
+	job.Start()
+	defer job.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := job.WaitForNextRun(ctx); err != nil {
+		t.Fatalf("expected the job to run every second, got %v", err)
+	}
+
+	if err := job.Reset("0 25 * * *"); !errors.Is(err, ErrInvalidSchedule) || job.ScheduleString() != "* * * * * *" {
+		t.Errorf("expected an invalid schedule to be refused and the old one kept, got %v", err)
+	}
+	if err := job.Reset("* * * * *"); err != nil {
+		t.Fatalf("Reset returned %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		at, ok := job.ArmedFireTime()
+		if ok && at.Second() == 0 && at.Nanosecond() == 0 && at.After(time.Now()) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the job to be rearmed on a minute boundary, got %v", at)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !job.IsRunning() || job.ScheduleString() != "* * * * *" {
+		t.Errorf("expected the job to keep running on its new schedule")
+	}
+	if next := job.NextRun(); next.Second() != 0 {
+		t.Errorf("expected the next run on a minute boundary, got %v", next)
+	}
+}
+
+// TestWithContextValue tests that values attached to a job stack and reach every run, even after WithContext.
+func TestWithContextValue(t *testing.T) {
+	type tenantKey struct{}
+	type regionKey struct{}
+	var got []interface{}
+	job := Schedule("* * * * *").SetBlocking(true).Execute(func(ctx context.Context) {
+		got = append(got, ctx.Value(tenantKey{}), ctx.Value(regionKey{}))
+	})
+	job.WithContextValue(tenantKey{}, "acme").WithContextValue(regionKey{}, "eu")
+	job.Trigger()
+	job.WithContextValue(tenantKey{}, "globex")
+	job.WithContext(context.Background())
+	job.Trigger()
+
+	want := []interface{}{"acme", "eu", "globex", "eu"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("runs saw %v, want %v", got, want)
+	}
+	if clone := job.Clone(); len(clone.values) != 3 {
+		t.Errorf("expected Clone to keep the values")
+	}
+}
+
+// TestWithContextWhileRunning tests that swapping the context of a running job keeps it scheduled
+// under the new context, which then stops it when cancelled.
+func TestWithContextWhileRunning(t *testing.T) {
+	var runs int64
+	var lastCtx atomic.Value
+	job := fastJob(5 * time.Millisecond).Execute(func(ctx context.Context) {
+		lastCtx.Store(ctx)
+		atomic.AddInt64(&runs, 1)
+	})
+	stopped := make(chan struct{}, 1)
+	job.OnStop(func() { stopped <- struct{}{} })
+	job.Start()
+	defer job.Stop()
+	old := job.Ctx
+
+	type key struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "swapped"))
+	defer cancel()
+	job.WithContext(ctx)
+	if old.Err() == nil {
+		t.Errorf("expected the previous context to be cancelled")
+	}
+	if !job.IsRunning() {
+		t.Fatalf("expected the job to keep running under its new context")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if atomic.LoadInt64(&runs) == 0 || lastCtx.Load().(context.Context).Value(key{}) != "swapped" {
+		t.Errorf("expected the job to run under its new context")
+	}
+	select {
+	case <-stopped:
+		t.Fatalf("expected swapping the context not to call OnStop")
+	default:
+	}
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatalf("expected cancelling the new context to stop the job")
+	}
+	if job.IsRunning() {
+		t.Errorf("expected the job to report it stopped")
+	}
+}
+
+// TestFromContext tests that a function shared by several jobs can tell from its context which run called it.
+func TestFromContext(t *testing.T) {
+	var seen []JobInfo
+	shared := func(ctx context.Context) {
+		info, ok := FromContext(ctx)
+		if !ok {
+			t.Errorf("expected the run's context to carry its info")
+		}
+		seen = append(seen, info)
+	}
+	backup := Schedule("0 9 * * *").SetName("backup").SetBlocking(true).Execute(shared)
+	report := Schedule("0 18 * * *").SetName("report").SetBlocking(true).Execute(shared)
+	backup.Trigger()
+	report.Trigger()
+	backup.Trigger()
+
+	want := []struct {
+		name string
+		run  uint64
+	}{{"backup", 1}, {"report", 1}, {"backup", 2}}
+	if len(seen) != len(want) {
+		t.Fatalf("expected %d runs, got %v", len(want), seen)
+	}
+	for i, w := range want {
+		if seen[i].Name != w.name || seen[i].Run != w.run || seen[i].FireTime.IsZero() {
+			t.Errorf("run %d: expected %s run #%d, got %+v", i, w.name, w.run, seen[i])
+		}
+	}
+
+	if _, ok := FromContext(context.Background()); ok {
+		t.Errorf("expected no info outside of a run")
+	}
+}
+
+// TestDryRun tests that a dry run logs each fire time instead of calling the function.
+func TestDryRun(t *testing.T) {
+	logger := &recordingLogger{}
+	var calls int64
+	job := fastJob(10 * time.Millisecond).SetName("preview").SetBlocking(true).WithLogger(logger).DryRun(true).Execute(func(ctx context.Context) {
+		atomic.AddInt64(&calls, 1)
+	})
+	job.Start()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := job.WaitForNextRun(ctx); err != nil {
+		t.Errorf("expected WaitForNextRun to return after a dry run, got %v", err)
+	}
+	time.Sleep(25 * time.Millisecond)
+	job.Stop()
+
+	if n := atomic.LoadInt64(&calls); n != 0 {
+		t.Errorf("expected the function not to be called, got %d calls", n)
+	}
+	if job.RunCount() != 0 || job.DryRunCount() == 0 {
+		t.Errorf("expected dry runs to be counted apart, got %d runs and %d dry runs", job.RunCount(), job.DryRunCount())
+	}
+	logger.mutex.Lock()
+	logged := strings.Join(logger.lines, "\n")
+	logger.mutex.Unlock()
+	if !strings.Contains(logged, "cron: job dry run job=preview") {
+		t.Errorf("expected the dry runs to be logged, got:\n%s", logged)
+	}
+
+	job.DryRun(false).Trigger()
+	if atomic.LoadInt64(&calls) != 1 || job.RunCount() != 1 {
+		t.Errorf("expected the function to run once dry runs are off")
+	}
+}
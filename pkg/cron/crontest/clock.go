@@ -0,0 +1,109 @@
+// Package crontest provides helpers for testing code built on package cron.
+package crontest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ekeric13/cron/pkg/cron"
+)
+
+// FakeClock is a cron.Clock whose time only moves when Advance or Set is called,
+// so tests can fire a Job's timers instantly and deterministically. Pass it to Job.WithClock.
+type FakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+	// timers are the timers that have neither fired nor been stopped
+	timers []*fakeTimer
+	// changed is signalled whenever timers changes, see BlockUntil
+	changed *sync.Cond
+}
+
+// NewFakeClock returns a FakeClock that reads now until it is moved.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.changed = sync.NewCond(&c.mutex)
+	return c
+}
+
+// Now returns the clock's current time.
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// NewTimer returns a timer that fires once the clock has been moved d past its current time.
+// A timer for zero or a negative duration fires straight away.
+func (c *FakeClock) NewTimer(d time.Duration) cron.Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	t := &fakeTimer{clock: c, at: c.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.ch <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	c.changed.Broadcast()
+	return t
+}
+
+// Advance moves the clock forward by d, firing every timer that comes due along the way.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set moves the clock to t, firing every timer due by then in the order of their fire times.
+// Each timer receives its own fire time, as a real timer firing on time would.
+// Moving the clock backwards fires nothing.
+func (c *FakeClock) Set(t time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = t
+	sort.SliceStable(c.timers, func(a, b int) bool { return c.timers[a].at.Before(c.timers[b].at) })
+	pending := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.at.After(t) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.ch <- timer.at
+	}
+	c.timers = pending
+	c.changed.Broadcast()
+}
+
+// BlockUntil waits until at least n timers are pending, for example until a started Job
+// has armed its timer, so that a following Advance is sure to fire it.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for len(c.timers) < n {
+		c.changed.Wait()
+	}
+}
+
+// fakeTimer is a timer handed out by a FakeClock.
+type fakeTimer struct {
+	clock *FakeClock
+	at    time.Time
+	ch    chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+// Stop removes the timer from its clock and reports whether it was still pending.
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i, timer := range c.timers {
+		if timer == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			c.changed.Broadcast()
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package crontest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ekeric13/cron/pkg/cron"
+)
+
+// TestFakeClockTimers tests that timers fire in order once the clock moves past them, and not before.
+func TestFakeClockTimers(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	late := clock.NewTimer(2 * time.Second)
+	early := clock.NewTimer(time.Second)
+	stopped := clock.NewTimer(time.Second)
+	if !stopped.Stop() {
+		t.Errorf("expected stopping a pending timer to report true")
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-early.C():
+		t.Fatalf("timer fired before its time")
+	default:
+	}
+
+	clock.Advance(2 * time.Second)
+	if got := <-early.C(); !got.Equal(start.Add(time.Second)) {
+		t.Errorf("expected the early timer to receive its fire time, got %v", got)
+	}
+	if got := <-late.C(); !got.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("expected the late timer to receive its fire time, got %v", got)
+	}
+	select {
+	case <-stopped.C():
+		t.Errorf("a stopped timer fired")
+	default:
+	}
+	if late.Stop() {
+		t.Errorf("expected stopping a fired timer to report false")
+	}
+}
+
+// TestFakeClockJob tests that a Job on a FakeClock fires as soon as the clock is advanced, without real waiting.
+func TestFakeClockJob(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC)
+	clock := NewFakeClock(start)
+	ran := make(chan time.Time, 1)
+	job := cron.Schedule("0 * * * *").WithClock(clock).SetBlocking(true).Execute(func(ctx context.Context) {
+		ran <- clock.Now()
+	})
+	job.Start()
+	defer job.Stop()
+
+	for hour := 1; hour <= 3; hour++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Hour)
+		select {
+		case <-ran:
+		case <-time.After(time.Second):
+			t.Fatalf("hour %d: the job did not fire when the clock was advanced", hour)
+		}
+	}
+	if job.RunCount() != 3 {
+		t.Errorf("expected 3 runs, got %d", job.RunCount())
+	}
+	if want := start.Add(3 * time.Hour); !job.LastRun().Equal(want) {
+		t.Errorf("expected the last run to be stamped with the fake time %v, got %v", want, job.LastRun())
+	}
+}
+
+// TestFakeClockNoDrift tests that late wake-ups don't push an interval job's fire times off its schedule.
+func TestFakeClockNoDrift(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	fired := make(chan time.Time, 1)
+	job := cron.Every(10 * time.Second).WithClock(clock).SetBlocking(true).Execute(func(ctx context.Context) {
+		info, _ := cron.FromContext(ctx)
+		fired <- info.FireTime
+	})
+	job.Start()
+	defer job.Stop()
+
+	for i := 1; i <= 100; i++ {
+		clock.BlockUntil(1)
+		// every wake-up comes a little late, as real timers do
+		clock.Advance(10*time.Second + 3*time.Millisecond)
+		select {
+		case at := <-fired:
+			if want := start.Add(time.Duration(i) * 10 * time.Second); !at.Equal(want) {
+				t.Fatalf("run %d fired at %v, want %v", i, at, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("run %d: the job did not fire when the clock was advanced", i)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// descriptors spells out the descriptors robfig understands, other than "@every", as the expressions they stand for.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// fieldUnit tells describeField how to word the values of one field of an expression.
+type fieldUnit struct {
+	// one and many name a single value and several of them; they are empty for months and weekdays,
+	// whose values are names of their own
+	one, many string
+	// prep introduces specific values, as in "at minute 5" or "on Monday"; hours have none
+	// since they follow the smaller units, as in "every 15 minutes past hour 9"
+	prep     string
+	min, max int
+	// names are the values' names, indexed from min
+	names []string
+}
+
+var (
+	millisecondUnit = fieldUnit{one: "millisecond", many: "milliseconds", prep: "at", min: 0, max: 999}
+	secondUnit      = fieldUnit{one: "second", many: "seconds", prep: "at", min: 0, max: 59}
+	minuteUnit      = fieldUnit{one: "minute", many: "minutes", prep: "at", min: 0, max: 59}
+	hourUnit        = fieldUnit{one: "hour", many: "hours", min: 0, max: 23}
+	dayUnit         = fieldUnit{one: "day", many: "days", prep: "on", min: 1, max: 31}
+	monthUnit       = fieldUnit{prep: "in", min: 1, max: 12, names: []string{
+		"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December",
+	}}
+	weekdayUnit = fieldUnit{prep: "on", min: 0, max: 6, names: []string{
+		"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday",
+	}}
+)
+
+// Describe turns a schedule string into an English sentence for people who don't read cron syntax,
+// for example "0 9 * * 1-5" into "At 09:00, Monday through Friday." and "*/15 * * * *" into "Every 15 minutes.".
+// It understands everything Schedule does: ranges, steps, lists, month and weekday names, the seconds and
+// milliseconds fields, descriptors such as "@daily", intervals and several schedules separated by semicolons.
+// Times are given on a 24-hour clock. It returns the error ScheduleE would for an invalid string.
+func Describe(scheduleStr string) (string, error) {
+	if err := Validate(scheduleStr); err != nil {
+		return "", err
+	}
+	var sentences []string
+	for i, part := range strings.Split(scheduleStr, ";") {
+		sentence := describe(strings.Fields(part))
+		if i > 0 {
+			sentence = strings.ToLower(sentence[:1]) + sentence[1:]
+		}
+		sentences = append(sentences, sentence)
+	}
+	return strings.Join(sentences, "; ") + ".", nil
+}
+
+// Describe returns the Job's schedule in English, see the Describe function. Jobs built with AfterEach
+// and AfterJob are described too.
+func (j *Job) Describe() string {
+	scheduleStr := j.ScheduleString()
+	switch fields := strings.Fields(scheduleStr); scheduleKind(scheduleStr) {
+	case kindAfterEach:
+		return fmt.Sprintf("%s after each run finishes.", strings.Join(fields[1:], " "))
+	case kindAfterJob:
+		return fmt.Sprintf("%s after the job it follows runs.", fields[1])
+	}
+	description, err := Describe(scheduleStr)
+	if err != nil {
+		return scheduleStr
+	}
+	return description
+}
+
+// describe words a single valid schedule, without the final full stop.
+func describe(fields []string) string {
+	var zone string
+	if strings.HasPrefix(fields[0], "TZ=") || strings.HasPrefix(fields[0], "CRON_TZ=") {
+		zone = fields[0][strings.Index(fields[0], "=")+1:]
+		fields = fields[1:]
+	}
+	if fields[0] == "@every" {
+		d, _ := time.ParseDuration(fields[1])
+		return "Every " + d.String()
+	}
+	if expr, ok := descriptors[fields[0]]; ok {
+		fields = strings.Fields(expr)
+	}
+
+	// fill in the optional leading fields so every expression has all seven
+	for len(fields) < 7 {
+		fields = append([]string{"0"}, fields...)
+	}
+	for i, field := range fields {
+		if field == "?" {
+			fields[i] = "*"
+		}
+	}
+
+	sentence := describeTime(fields[0], fields[1], fields[2], fields[3])
+	if date := describeDate(fields[4], fields[5], fields[6]); date != "" {
+		sentence += ", " + date
+	}
+	if zone != "" {
+		sentence += ", in time zone " + zone
+	}
+	return strings.ToUpper(sentence[:1]) + sentence[1:]
+}
+
+// describeTime words the milliseconds, seconds, minutes and hours of an expression.
+// A single time of day, or a few with the same minutes, read as a clock such as "at 09:00".
+func describeTime(millis, seconds, minutes, hours string) string {
+	ms, msOk := single(millis, millisecondUnit)
+	s, sOk := single(seconds, secondUnit)
+	m, mOk := single(minutes, minuteUnit)
+	if msOk && sOk && mOk {
+		var clocks []string
+		for _, item := range strings.Split(hours, ",") {
+			h, ok := single(item, hourUnit)
+			if !ok {
+				clocks = nil
+				break
+			}
+			clock := fmt.Sprintf("%02d:%02d", h, m)
+			if s != 0 || ms != 0 {
+				clock += fmt.Sprintf(":%02d", s)
+			}
+			if ms != 0 {
+				clock += fmt.Sprintf(".%03d", ms)
+			}
+			clocks = append(clocks, clock)
+		}
+		if clocks != nil {
+			return "at " + joinAnd(clocks)
+		}
+	}
+
+	// otherwise each field is worded on its own, leaving out what goes without saying: a zero millisecond,
+	// a zero second unless milliseconds are set, every hour, and every second or minute when a smaller unit is set
+	var phrases []string
+	if millis != "0" {
+		phrases = append(phrases, describeField(millis, millisecondUnit))
+	}
+	if !(seconds == "0" && len(phrases) == 0) && !(seconds == "*" && len(phrases) > 0) {
+		phrases = append(phrases, describeField(seconds, secondUnit))
+	}
+	if !(minutes == "*" && len(phrases) > 0) {
+		phrases = append(phrases, describeField(minutes, minuteUnit))
+	}
+	if hours != "*" {
+		phrases = append(phrases, describeField(hours, hourUnit))
+	}
+	for i := 1; i < len(phrases); i++ {
+		// "past" takes the place of "at" for the larger units
+		phrases[i] = strings.TrimPrefix(phrases[i], "at ")
+	}
+	return strings.Join(phrases, " past ")
+}
+
+// describeDate words the day of month, month and day of week of an expression, or returns ""
+// for an expression that fires every day.
+func describeDate(days, months, weekdays string) string {
+	var phrases []string
+	d, dOk := single(days, dayUnit)
+	m, mOk := single(months, monthUnit)
+	if dOk && mOk && weekdays == "*" {
+		phrases = append(phrases, fmt.Sprintf("on %d %s", d, monthUnit.names[m-monthUnit.min]))
+	} else {
+		if days != "*" {
+			phrases = append(phrases, describeField(days, dayUnit)+" of the month")
+		}
+		if months != "*" {
+			phrases = append(phrases, describeField(months, monthUnit))
+		}
+	}
+	if weekdays != "*" {
+		weekday := describeField(weekdays, weekdayUnit)
+		// cron fires when either day matches if both are restricted
+		if days != "*" {
+			phrases[0] += " or " + weekday
+		} else {
+			phrases = append(phrases, weekday)
+		}
+	}
+	return strings.Join(phrases, ", ")
+}
+
+// describeField words one field of an expression, such as "every 15 minutes", "at minutes 0 and 30"
+// or "Monday through Friday".
+func describeField(field string, u fieldUnit) string {
+	items := strings.Split(field, ",")
+	var values []string
+	for _, item := range items {
+		v, ok := single(item, u)
+		if !ok {
+			values = nil
+			break
+		}
+		values = append(values, u.value(v))
+	}
+	if values != nil {
+		if u.one == "" {
+			return u.at(joinAnd(values))
+		}
+		if len(values) == 1 {
+			return u.at(u.one + " " + values[0])
+		}
+		return u.at(u.many + " " + joinAnd(values))
+	}
+
+	phrases := make([]string, len(items))
+	for i, item := range items {
+		phrases[i] = describeItem(item, u)
+	}
+	return joinAnd(phrases)
+}
+
+// describeItem words one item of a list: "*", a value, a range, or either of the latter two with a step.
+func describeItem(item string, u fieldUnit) string {
+	rangePart, step := item, 1
+	if i := strings.Index(item, "/"); i >= 0 {
+		rangePart = item[:i]
+		step, _ = strconv.Atoi(item[i+1:])
+	}
+	every := "every " + u.one
+	if u.one == "" {
+		every = "every day"
+		if u.max == 12 {
+			every = "every month"
+		}
+	}
+	if step > 1 {
+		noun := u.many
+		if u.one == "" {
+			noun = strings.TrimPrefix(every, "every ") + "s"
+		}
+		every = fmt.Sprintf("every %d %s", step, noun)
+	}
+
+	low, high := u.min, u.max
+	switch {
+	case rangePart == "*":
+		if step == 1 {
+			return every
+		}
+	case strings.Contains(rangePart, "-"):
+		bounds := strings.SplitN(rangePart, "-", 2)
+		low, high = u.parse(bounds[0]), u.parse(bounds[1])
+		if step == 1 && u.one == "" {
+			return u.value(low) + " through " + u.value(high)
+		}
+	default:
+		low = u.parse(rangePart)
+		if step == 1 {
+			if u.one == "" {
+				return u.at(u.value(low))
+			}
+			return u.at(u.one + " " + u.value(low))
+		}
+	}
+	if low == u.min && high == u.max {
+		return every
+	}
+	return fmt.Sprintf("%s from %s through %s", every, u.value(low), u.value(high))
+}
+
+// single returns the value of a field that is a single value.
+func single(field string, u fieldUnit) (int, bool) {
+	if field == "" || field == "*" || strings.ContainsAny(field, ",-/") {
+		return 0, false
+	}
+	return u.parse(field), true
+}
+
+// parse reads a value of the unit, given as a number or, for months and weekdays, a name's first three letters.
+func (u fieldUnit) parse(s string) int {
+	for i, name := range u.names {
+		if strings.EqualFold(s, name[:3]) {
+			return u.min + i
+		}
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// at introduces specific values of the unit.
+func (u fieldUnit) at(s string) string {
+	if u.prep == "" {
+		return s
+	}
+	return u.prep + " " + s
+}
+
+// value words a value of the unit.
+func (u fieldUnit) value(v int) string {
+	if u.names != nil && v >= u.min && v-u.min < len(u.names) {
+		return u.names[v-u.min]
+	}
+	return strconv.Itoa(v)
+}
+
+// joinAnd joins words as in "a, b and c".
+func joinAnd(words []string) string {
+	if len(words) == 1 {
+		return words[0]
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+}
@@ -0,0 +1,59 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestDescribe tests the English descriptions of common schedules.
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		schedule string
+		want     string
+	}{
+		{"* * * * *", "Every minute."},
+		{"*/15 * * * *", "Every 15 minutes."},
+		{"5 * * * *", "At minute 5."},
+		{"0 9 * * 1-5", "At 09:00, Monday through Friday."},
+		{"0 8,20 * * *", "At 08:00 and 20:00."},
+		{"*/15 9-17 * * *", "Every 15 minutes past every hour from 9 through 17."},
+		{"0 */2 * * *", "At minute 0 past every 2 hours."},
+		{"5/15 * * * *", "Every 15 minutes from 5 through 59."},
+		{"0,10-20 * * * *", "At minute 0 and every minute from 10 through 20."},
+		{"30 0 9 * * *", "At 09:00:30."},
+		{"*/10 * * * * *", "Every 10 seconds."},
+		{"0,30 * * * * *", "At seconds 0 and 30."},
+		{"*/15 5 * * * *", "Every 15 seconds past minute 5."},
+		{"500 0 * * * * *", "At millisecond 500 past second 0."},
+		{"0 0 1,15 * *", "At 00:00, on days 1 and 15 of the month."},
+		{"0 0 */2 * *", "At 00:00, every 2 days of the month."},
+		{"0 0 1 * 1", "At 00:00, on day 1 of the month or on Monday."},
+		{"0 9 * JAN-MAR MON,WED,FRI", "At 09:00, January through March, on Monday, Wednesday and Friday."},
+		{"0 12 ? * SUN", "At 12:00, on Sunday."},
+		{"@yearly", "At 00:00, on 1 January."},
+		{"@hourly", "At minute 0."},
+		{"@every 90s", "Every 1m30s."},
+		{"CRON_TZ=Asia/Tokyo 0 9 * * *", "At 09:00, in time zone Asia/Tokyo."},
+		{"0 8 * * *; 30 20 * * 5", "At 08:00; at 20:30, on Friday."},
+	}
+	for _, test := range tests {
+		got, err := Describe(test.schedule)
+		if err != nil {
+			t.Errorf("Describe(%q) returned an error: %v", test.schedule, err)
+		} else if got != test.want {
+			t.Errorf("Describe(%q) = %q, want %q", test.schedule, got, test.want)
+		}
+	}
+
+	if _, err := Describe("0 25 * * *"); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("expected an invalid schedule error, got %v", err)
+	}
+
+	if got := Schedule("0 9 * * 1-5").Describe(); got != "At 09:00, Monday through Friday." {
+		t.Errorf("Job.Describe returned %q", got)
+	}
+	if got := AfterEach(time.Minute).Describe(); got != "1m0s after each run finishes." {
+		t.Errorf("Job.Describe returned %q for a fixed-delay job", got)
+	}
+}
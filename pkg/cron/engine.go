@@ -0,0 +1,216 @@
+package cron
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// fireEntry is a job waiting in a heapEngine for its next fire time.
+// Only the entry matching the job's latest seq is live; older ones were superseded by a rearm.
+type fireEntry struct {
+	at time.Time
+	// intended is at without jitter, the scheduled time the job's next fire time follows on from, see Job.arm
+	intended time.Time
+	job      *Job
+	done     <-chan struct{}
+	seq      uint64
+}
+
+// fireQueue is a min-heap of fireEntry ordered by fire time.
+type fireQueue []fireEntry
+
+func (q fireQueue) Len() int            { return len(q) }
+func (q fireQueue) Less(a, b int) bool  { return q[a].at.Before(q[b].at) }
+func (q fireQueue) Swap(a, b int)       { q[a], q[b] = q[b], q[a] }
+func (q *fireQueue) Push(x interface{}) { *q = append(*q, x.(fireEntry)) }
+func (q *fireQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+// heapEngine drives many jobs from a single goroutine and timer instead of one of each per job.
+// Jobs whose context is done are dropped the next time they reach the top of the heap.
+type heapEngine struct {
+	queue fireQueue
+	// seq is each job's latest entry, busy marks jobs with a blocking run in progress
+	seq  map[*Job]uint64
+	busy map[*Job]bool
+	wake chan struct{}
+	// stop ends the scheduling goroutine, which closes exited once it has returned
+	stop   chan struct{}
+	exited chan struct{}
+	mutex  sync.Mutex
+}
+
+// newHeapEngine starts an engine's scheduling goroutine.
+func newHeapEngine() *heapEngine {
+	e := &heapEngine{
+		seq:    make(map[*Job]uint64),
+		busy:   make(map[*Job]bool),
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+	go e.loop()
+	return e
+}
+
+// add hands a started job over to the engine.
+func (e *heapEngine) add(j *Job, done <-chan struct{}) {
+	j.mutex.Lock()
+	j.rearm = func() { e.rearm(j, done) }
+	j.mutex.Unlock()
+	if j.takeRunFirst() {
+		now := time.Now()
+		e.fire(fireEntry{at: now, job: j, done: done}, func() { j.dispatch(now, false, false) })
+		return
+	}
+	e.schedule(j, done, time.Time{})
+}
+
+// rearm reschedules a job whose schedule changed, unless a blocking run of it is
+// in progress and will reschedule it when it returns.
+func (e *heapEngine) rearm(j *Job, done <-chan struct{}) {
+	e.mutex.Lock()
+	busy := e.busy[j]
+	e.mutex.Unlock()
+	if !busy {
+		e.schedule(j, done, time.Time{})
+	}
+}
+
+// schedule queues the job's next fire time following on from prev, superseding any entry already queued for it.
+// Jobs with nothing upcoming are left out until they are rearmed.
+func (e *heapEngine) schedule(j *Job, done <-chan struct{}, prev time.Time) {
+	at, intended, _ := j.arm(prev)
+	e.mutex.Lock()
+	e.seq[j]++
+	if at.IsZero() {
+		e.mutex.Unlock()
+		return
+	}
+	heap.Push(&e.queue, fireEntry{at: at, intended: intended, job: j, done: done, seq: e.seq[j]})
+	e.mutex.Unlock()
+
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// shutdown stops the scheduling goroutine without waiting for it, see exited. Queued jobs are left untouched.
+func (e *heapEngine) shutdown() {
+	close(e.stop)
+}
+
+// loop sleeps until the earliest fire time, fires every due job, and repeats.
+func (e *heapEngine) loop() {
+	defer close(e.exited)
+	for {
+		var timer Timer
+		var expired <-chan time.Time
+		e.mutex.Lock()
+		if len(e.queue) > 0 {
+			timer = realClock{}.NewTimer(time.Until(e.queue[0].at))
+			expired = timer.C()
+		}
+		e.mutex.Unlock()
+
+		select {
+		case <-expired:
+			e.fireDue()
+		case <-e.wake:
+			stopTimer(timer)
+		case <-e.stop:
+			stopTimer(timer)
+			return
+		}
+	}
+}
+
+// fireDue pops every entry whose time has come and fires it.
+// Non-blocking jobs are rescheduled straight away; blocking jobs run on their own goroutine
+// and are rescheduled once the run returns, matching the per-job loop.
+func (e *heapEngine) fireDue() {
+	now := time.Now()
+	for {
+		e.mutex.Lock()
+		if len(e.queue) == 0 || e.queue[0].at.After(now) {
+			e.mutex.Unlock()
+			return
+		}
+		entry := heap.Pop(&e.queue).(fireEntry)
+		stale := entry.seq != e.seq[entry.job]
+		e.mutex.Unlock()
+		if stale {
+			continue
+		}
+
+		select {
+		case <-entry.done:
+			e.retire(entry)
+			continue
+		default:
+		}
+
+		e.fire(entry, func() { entry.job.tick(entry.at, false) })
+	}
+}
+
+// fire calls run for an entry's job and then reschedules it.
+// Blocking jobs run on their own goroutine and are marked busy until the run returns.
+func (e *heapEngine) fire(entry fireEntry, run func()) {
+	if !entry.job.blocking() {
+		run()
+		e.reschedule(entry)
+		return
+	}
+	e.mutex.Lock()
+	e.busy[entry.job] = true
+	e.mutex.Unlock()
+	go func() {
+		run()
+		e.mutex.Lock()
+		delete(e.busy, entry.job)
+		e.mutex.Unlock()
+		e.reschedule(entry)
+	}()
+}
+
+// reschedule queues an entry's job again unless the engine or the job has been stopped.
+func (e *heapEngine) reschedule(entry fireEntry) {
+	select {
+	case <-e.stop:
+		return
+	case <-entry.done:
+		e.retire(entry)
+		return
+	default:
+	}
+	e.schedule(entry.job, entry.done, entry.intended)
+}
+
+// retire handles an entry whose job's context is done. A job that WithContext moved to a new
+// context is scheduled again under it; any other is forgotten and marked as stopped.
+func (e *heapEngine) retire(entry fireEntry) {
+	j := entry.job
+	if done := j.retarget(entry.done); done != nil {
+		j.mutex.Lock()
+		j.rearm = func() { e.rearm(j, done) }
+		j.mutex.Unlock()
+		e.schedule(j, done, time.Time{})
+		return
+	}
+	e.forget(j)
+	j.finish(entry.done)
+}
+
+// forget drops the engine's bookkeeping for a stopped job.
+func (e *heapEngine) forget(j *Job) {
+	e.mutex.Lock()
+	delete(e.seq, j)
+	e.mutex.Unlock()
+}
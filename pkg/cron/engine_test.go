@@ -0,0 +1,148 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestManagerSharedLoop tests that StartAll drives all jobs without a goroutine per job.
+func TestManagerSharedLoop(t *testing.T) {
+	const jobs = 100
+	var runs int64
+	m := NewManager()
+	for i := 0; i < jobs; i++ {
+		m.Add(fmt.Sprintf("job-%d", i), fastJob(20*time.Millisecond).Execute(func(ctx context.Context) {
+			atomic.AddInt64(&runs, 1)
+		}))
+	}
+
+	before := runtime.NumGoroutine()
+	m.StartAll()
+	if grown := runtime.NumGoroutine() - before; grown > 5 {
+		t.Errorf("StartAll added %d goroutines for %d idle jobs", grown, jobs)
+	}
+	time.Sleep(110 * time.Millisecond)
+	m.StopAll()
+
+	if got := atomic.LoadInt64(&runs); got < jobs {
+		t.Errorf("expected every job to run at least once, got %d runs", got)
+	}
+	j, _ := m.Job("job-0")
+	if j.isRunning {
+		t.Errorf("StopAll left a job running")
+	}
+}
+
+// TestManagerSharedLoopBlocking tests that blocking jobs are rescheduled only after their run returns.
+func TestManagerSharedLoopBlocking(t *testing.T) {
+	var active, peak int64
+	m := NewManager()
+	m.Add("slow", fastJob(5*time.Millisecond).SetBlocking(true).Execute(func(ctx context.Context) {
+		if n := atomic.AddInt64(&active, 1); n > atomic.LoadInt64(&peak) {
+			atomic.StoreInt64(&peak, n)
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt64(&active, -1)
+	}))
+
+	m.StartAll()
+	time.Sleep(100 * time.Millisecond)
+	m.StopAll()
+
+	if peak != 1 {
+		t.Errorf("expected blocking runs to never overlap, peak was %d", peak)
+	}
+}
+
+// benchmarkScheduling starts 1000 jobs firing every 20ms with start, lets them run,
+// and reports the mean delay between each scheduled time and the run actually starting,
+// plus the goroutines and heap and stack memory held while the jobs were idle.
+func benchmarkScheduling(b *testing.B, start func(jobs []*Job), stop func(jobs []*Job)) {
+	const count = 1000
+	var lateness, fired, goroutines, memBytes int64
+	for i := 0; i < b.N; i++ {
+		jobs := make([]*Job, count)
+		for n := range jobs {
+			jobs[n] = fastJob(20 * time.Millisecond).Execute(func(ctx context.Context) {})
+			jobs[n].observer = func(fireTime time.Time, duration time.Duration, err error) {
+				atomic.AddInt64(&lateness, int64(time.Since(fireTime)-duration))
+				atomic.AddInt64(&fired, 1)
+			}
+		}
+
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		goroutinesBefore := runtime.NumGoroutine()
+		start(jobs)
+		runtime.ReadMemStats(&after)
+		goroutines += int64(runtime.NumGoroutine() - goroutinesBefore)
+		memBytes += int64(after.HeapInuse+after.StackInuse) - int64(before.HeapInuse+before.StackInuse)
+
+		time.Sleep(200 * time.Millisecond)
+		stop(jobs)
+	}
+	if fired > 0 {
+		b.ReportMetric(float64(lateness/fired)/float64(time.Microsecond), "late-µs/run")
+	}
+	b.ReportMetric(float64(goroutines)/float64(b.N), "goroutines")
+	b.ReportMetric(float64(memBytes)/float64(b.N), "mem-B")
+}
+
+// BenchmarkPerJobLoop measures 1000 jobs each running their own Start loop.
+func BenchmarkPerJobLoop(b *testing.B) {
+	benchmarkScheduling(b, func(jobs []*Job) {
+		for _, j := range jobs {
+			j.Start()
+		}
+	}, func(jobs []*Job) {
+		for _, j := range jobs {
+			j.Stop()
+		}
+	})
+}
+
+// BenchmarkManagerHeap measures 1000 jobs driven by a single Manager goroutine.
+func BenchmarkManagerHeap(b *testing.B) {
+	var m *Manager
+	benchmarkScheduling(b, func(jobs []*Job) {
+		m = NewManager()
+		for n, j := range jobs {
+			// registered directly so each job keeps the benchmark's observer
+			m.jobs[fmt.Sprint(n)] = j
+		}
+		m.StartAll()
+	}, func(jobs []*Job) {
+		m.StopAll()
+	})
+}
+
+// TestManagerSharedLoopRearm tests that jobs parked with nothing upcoming fire once woken.
+func TestManagerSharedLoopRearm(t *testing.T) {
+	first := Schedule("0 0 1 1 *").Execute(func(ctx context.Context) {})
+	fired := make(chan struct{}, 1)
+	second := Schedule("* * * * *").AfterJob(first, 10*time.Millisecond).Execute(func(ctx context.Context) {
+		fired <- struct{}{}
+	})
+
+	m := NewManager()
+	m.Add("second", second)
+	m.StartAll()
+	defer m.StopAll()
+
+	first.run(time.Now())
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatalf("parked job did not fire after being rearmed")
+	}
+	select {
+	case <-fired:
+		t.Errorf("job fired twice for a single run of the job it follows")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
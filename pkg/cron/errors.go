@@ -0,0 +1,52 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidSchedule matches every error reporting a schedule that can't be parsed, see ScheduleError.
+	ErrInvalidSchedule = errors.New("cron: invalid schedule")
+	// ErrNoFunc is returned by StartE for a Job without a function.
+	ErrNoFunc = errors.New("cron: job has no function")
+	// ErrAlreadyRunning is returned by StartE for a Job that is already running.
+	ErrAlreadyRunning = errors.New("cron: job is already running")
+	// ErrStopped is returned by WaitForNextRun when the Job stops before running again.
+	ErrStopped = errors.New("cron: job stopped")
+	// ErrFieldCount is wrapped by the error ScheduleE returns for an expression without 5, 6 or 7 fields,
+	// telling a malformed expression apart from one with an out of range value. It has no "cron:" prefix
+	// since it is only ever reported inside a ScheduleError, which has one.
+	ErrFieldCount = errors.New("wrong number of fields")
+	// ErrContextDone is returned by StartE when the context given to WithContext is already cancelled or past its deadline.
+	ErrContextDone = errors.New("cron: job context is done")
+	// ErrRuntimeBudgetExceeded is the StopCause of a Job that used up its MaxCumulativeRuntime.
+	ErrRuntimeBudgetExceeded = errors.New("cron: cumulative runtime budget exceeded")
+	// ErrMaxRunsReached is the StopCause of a Job that ran as many times as MaxRuns allows,
+	// and is returned by StartE for such a Job.
+	ErrMaxRunsReached = errors.New("cron: maximum number of runs reached")
+	// ErrInvalidConfig is wrapped by the errors Build returns for a Job that is not set up correctly.
+	ErrInvalidConfig = errors.New("cron: invalid job configuration")
+	// ErrEndTimeReached is the StopCause of a Job whose next fire time fell at or after the end set with Until.
+	ErrEndTimeReached = errors.New("cron: end time reached")
+)
+
+// ScheduleError reports a schedule string that can't be parsed. It matches ErrInvalidSchedule with errors.Is,
+// and unwraps to the underlying reason, such as an error from the robfig parser or ErrFieldCount.
+type ScheduleError struct {
+	Schedule string
+	Err      error
+}
+
+func (e *ScheduleError) Error() string {
+	return fmt.Sprintf("cron: invalid schedule %q: %v", e.Schedule, e.Err)
+}
+
+func (e *ScheduleError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrInvalidSchedule.
+func (e *ScheduleError) Is(target error) bool {
+	return target == ErrInvalidSchedule
+}
@@ -0,0 +1,34 @@
+package cron
+
+import (
+	"context"
+	"time"
+)
+
+// JobInfo describes the run a function was called for, see FromContext.
+type JobInfo struct {
+	// Name is the Job's Name, empty if it has none.
+	Name string `json:"name,omitempty"`
+	// FireTime is the time the run was scheduled for.
+	FireTime time.Time `json:"fire_time"`
+	// Run is the run's number, counting from 1, as RunCount reports it once the run started.
+	Run uint64 `json:"run"`
+}
+
+// infoKey is the unexported key JobInfo is stored under, so only FromContext can read it.
+type infoKey struct{}
+
+// FromContext returns the JobInfo of the run ctx was passed to, so a function shared by several jobs
+// can tell which one called it. It reports false for a context that didn't come from a Job, and the
+// info is inherited by contexts derived from it. The functions set with OnBeforeRun and OnAfterRun
+// and the Tracer set with WithTracer receive it too.
+func FromContext(ctx context.Context) (JobInfo, bool) {
+	info, ok := ctx.Value(infoKey{}).(JobInfo)
+	return info, ok
+}
+
+// withInfo returns ctx carrying info. It costs one small allocation per run, and nothing more
+// unless a function looks the info up.
+func withInfo(ctx context.Context, info JobInfo) context.Context {
+	return context.WithValue(ctx, infoKey{}, info)
+}
@@ -0,0 +1,53 @@
+package cron
+
+// Logger receives a Job's diagnostics as a message followed by alternating key-value pairs.
+// Its method set matches *slog.Logger, so one can be passed straight to WithLogger.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// nopLogger discards everything, it stands in when no Logger is set.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// WithLogger makes the Job log when its scheduling loop starts and stops at info level, each run at
+// debug level, and errors and panics from the function at error level, along with ticks skipped for
+// lack of a function and nil functions passed to Execute or ExecuteE. Every line carries the Job's Name under
+// the "job" key. Without a Logger the Job logs nothing.
+func (j *Job) WithLogger(l Logger) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.logger = l
+	return j
+}
+
+// log returns the Job's Logger, or one discarding everything. The caller holds the mutex.
+func (j *Job) log() Logger {
+	if j.logger == nil {
+		return nopLogger{}
+	}
+	return j.logger
+}
+
+// stopped returns what to call once the scheduling loop ends: closing halted, logging why, then onStop.
+// The caller holds the mutex.
+func (j *Job) stopped(cause error) func() {
+	logger, name, onStop, halted := j.log(), j.Name, j.onStop, j.halted
+	return func() {
+		close(halted)
+		args := []interface{}{"job", name}
+		if cause != nil {
+			args = append(args, "cause", cause)
+		}
+		logger.Info("cron: job stopped", args...)
+		if onStop != nil {
+			onStop()
+		}
+	}
+}
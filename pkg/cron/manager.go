@@ -0,0 +1,398 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// collisionSampleLimit caps how many fire times FindCollisions computes per job,
+// so very frequent schedules over a long horizon stay cheap.
+const collisionSampleLimit = 10000
+
+// eventBufferSize is the capacity of the Manager's event channel.
+// Events are dropped rather than queued once the buffer is full.
+const eventBufferSize = 256
+
+// ExecutionEvent describes a single execution of a job owned by a Manager.
+type ExecutionEvent struct {
+	// Job is the name the job was registered under.
+	Job string `json:"job"`
+	// FireTime is the time the execution was scheduled for.
+	FireTime time.Time `json:"fire_time"`
+	// Duration is how long the job's function took to return.
+	Duration time.Duration `json:"duration"`
+	// Err is the error reported by the execution, if any.
+	Err error `json:"-"`
+}
+
+// Manager groups named jobs so they can be started, stopped and observed together.
+type Manager struct {
+	jobs    map[string]*Job
+	events  chan ExecutionEvent
+	limiter chan struct{}
+	// engine drives every started job from one goroutine, it is nil until StartAll
+	engine *heapEngine
+	mutex  sync.RWMutex
+}
+
+// NewManager returns an empty Manager.
+func NewManager() *Manager {
+	return &Manager{
+		jobs:   make(map[string]*Job),
+		events: make(chan ExecutionEvent, eventBufferSize),
+	}
+}
+
+// Add registers a job under the given name, which also becomes the job's Name if it doesn't have one.
+// It returns an error if the name is already taken or the job belongs to another Manager.
+func (m *Manager) Add(name string, j *Job) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.add(name, j)
+}
+
+// add is Add for a caller holding the mutex.
+func (m *Manager) add(name string, j *Job) error {
+	if _, ok := m.jobs[name]; ok {
+		return fmt.Errorf("cron: job %q already exists", name)
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if j.observer != nil {
+		return fmt.Errorf("cron: job %q is already managed", name)
+	}
+	if j.Name == "" {
+		j.Name = name
+	}
+	j.observer = func(fireTime time.Time, duration time.Duration, err error) {
+		m.publish(ExecutionEvent{Job: name, FireTime: fireTime, Duration: duration, Err: err})
+	}
+	j.limiter = m.limiter
+	m.jobs[name] = j
+	return nil
+}
+
+// Remove unregisters the job with the given name. The job itself is not stopped.
+func (m *Manager) Remove(name string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	j, ok := m.jobs[name]
+	if !ok {
+		return
+	}
+	delete(m.jobs, name)
+
+	j.mutex.Lock()
+	j.observer = nil
+	j.limiter = nil
+	j.mutex.Unlock()
+}
+
+// Job returns the job registered under the given name.
+func (m *Manager) Job(name string) (*Job, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	j, ok := m.jobs[name]
+	return j, ok
+}
+
+// Names returns the names of all registered jobs in sorted order.
+func (m *Manager) Names() []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	names := make([]string, 0, len(m.jobs))
+	for name := range m.jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate reports configuration mistakes that would otherwise make StartAll silently skip a job:
+// jobs without a function, and jobs whose schedule never fires (such as "0 0 30 2 *").
+// A job built with AfterJob fires as long as the job it follows does, so that job's schedule is checked instead.
+// The returned error lists every problem found, or is nil if there are none.
+func (m *Manager) Validate() error {
+	var problems []string
+	for _, name := range m.Names() {
+		j, ok := m.Job(name)
+		if !ok {
+			continue
+		}
+		j.mutex.RLock()
+		hasFn := j.Fn != nil
+		j.mutex.RUnlock()
+		if !hasFn {
+			problems = append(problems, fmt.Sprintf("%q has no function", name))
+		}
+		if neverFires(j) {
+			problems = append(problems, fmt.Sprintf("%q never fires", name))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("cron: invalid jobs: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// neverFires reports whether j's schedule has no upcoming fire time, following AfterJob jobs back to
+// the first job of their chain. A chain that loops back on itself never fires either.
+func neverFires(j *Job) bool {
+	seen := make(map[*Job]bool)
+	for !seen[j] {
+		seen[j] = true
+		j.mutex.RLock()
+		after, ok := j.Schedule.(afterJobSchedule)
+		j.mutex.RUnlock()
+		if !ok {
+			return j.nextFire().IsZero()
+		}
+		j = after.other
+	}
+	return true
+}
+
+// FindCollisions returns the pairs of job names that fire at exactly the same instant
+// at least once between now and now+horizon, which hints at thundering-herd risk worth
+// staggering or adding jitter to. Each pair is ordered by name and the result is sorted.
+// At most 10000 fire times are considered per job. Intervals built with Every or AfterEach aren't tied to
+// the clock, so those jobs only count while running, from the fire time they are armed for, see
+// Job.ArmedFireTime, assuming runs of AfterEach jobs take no time.
+func (m *Manager) FindCollisions(horizon time.Duration) [][2]string {
+	now := time.Now()
+	end := now.Add(horizon)
+	firing := make(map[int64][]string)
+	for _, name := range m.Names() {
+		j, ok := m.Job(name)
+		if !ok {
+			continue
+		}
+		j.mutex.RLock()
+		float := floating(j.Schedule)
+		j.mutex.RUnlock()
+		at := j.nextFireAfter(now)
+		if float {
+			// only fixed once the job is running, counting from whenever it was started
+			armed, ok := j.ArmedFireTime()
+			if !ok {
+				continue
+			}
+			at = armed
+		}
+		for i := 0; i < collisionSampleLimit; i++ {
+			if at.IsZero() || at.After(end) {
+				break
+			}
+			firing[at.UnixNano()] = append(firing[at.UnixNano()], name)
+			at = j.nextFireAfter(at)
+		}
+	}
+
+	seen := make(map[[2]string]bool)
+	var pairs [][2]string
+	for _, names := range firing {
+		for a := 0; a < len(names); a++ {
+			for b := a + 1; b < len(names); b++ {
+				// names were collected in sorted order, so each pair is too
+				pair := [2]string{names[a], names[b]}
+				if !seen[pair] {
+					seen[pair] = true
+					pairs = append(pairs, pair)
+				}
+			}
+		}
+	}
+	sort.Slice(pairs, func(a, b int) bool {
+		if pairs[a][0] != pairs[b][0] {
+			return pairs[a][0] < pairs[b][0]
+		}
+		return pairs[a][1] < pairs[b][1]
+	})
+	return pairs
+}
+
+// StartAll starts every registered job that isn't already running.
+// Rather than each job running its own scheduling goroutine and timer, the Manager drives
+// all of them from a single goroutine that sleeps until the earliest upcoming fire time,
+// which keeps a large number of jobs cheap. Jobs added later are not started automatically.
+func (m *Manager) StartAll() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.engine == nil {
+		m.engine = newHeapEngine()
+	}
+	for _, j := range m.jobs {
+		if done, _ := j.begin(nil); done != nil {
+			m.engine.add(j, done)
+		}
+	}
+}
+
+// StopAll stops every registered job and the Manager's scheduling goroutine.
+func (m *Manager) StopAll() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, j := range m.jobs {
+		j.Stop()
+	}
+	if m.engine != nil {
+		m.engine.shutdown()
+		m.engine = nil
+	}
+}
+
+// SetGlobalConcurrency caps how many executions may run at once across all registered jobs.
+// Each execution takes a slot from a semaphore shared by the whole Manager before running;
+// when none is free the job waits or skips the tick according to its LimitPolicy.
+// A value of zero or less removes the cap. Executions already holding a slot are unaffected by a change.
+func (m *Manager) SetGlobalConcurrency(n int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if n > 0 {
+		m.limiter = make(chan struct{}, n)
+	} else {
+		m.limiter = nil
+	}
+	for _, j := range m.jobs {
+		j.mutex.Lock()
+		j.limiter = m.limiter
+		j.mutex.Unlock()
+	}
+}
+
+// ShutdownAndSnapshot stops every registered job, waits for in-flight executions to finish,
+// and returns each job's state keyed by name so it can be persisted and later handed to RestoreState.
+// If ctx is done before every execution has finished, the snapshot is still returned along with ctx's error.
+func (m *Manager) ShutdownAndSnapshot(ctx context.Context) (map[string]JobState, error) {
+	m.StopAll()
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	var err error
+	for _, j := range m.jobs {
+		if err = j.inflight.wait(ctx); err != nil {
+			break
+		}
+	}
+	states := make(map[string]JobState, len(m.jobs))
+	for name, j := range m.jobs {
+		states[name] = j.State()
+	}
+	return states, err
+}
+
+// RestoreState loads previously snapshotted state into the registered jobs with matching names.
+// Names without a registered job are ignored.
+func (m *Manager) RestoreState(states map[string]JobState) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	for name, state := range states {
+		if j, ok := m.jobs[name]; ok {
+			j.RestoreState(state)
+		}
+	}
+}
+
+// jobDefinition is the serialized form of a managed job used by ExportJSON and ImportJSON.
+type jobDefinition struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+	Timezone string `json:"timezone"`
+	Blocking bool   `json:"blocking"`
+}
+
+// ExportJSON serializes the definition of every registered job: its name, schedule, timezone and blocking setting.
+// Functions and runtime state are not included.
+func (m *Manager) ExportJSON() ([]byte, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	defs := make([]jobDefinition, 0, len(m.jobs))
+	for name, j := range m.jobs {
+		j.mutex.RLock()
+		defs = append(defs, jobDefinition{
+			Name:     name,
+			Schedule: j.scheduleStr,
+			Timezone: j.Timezone.String(),
+			Blocking: j.Blocking,
+		})
+		j.mutex.RUnlock()
+	}
+	sort.Slice(defs, func(a, b int) bool { return defs[a].Name < defs[b].Name })
+	return json.MarshalIndent(defs, "", "  ")
+}
+
+// ImportJSON registers the jobs described by data, as produced by ExportJSON.
+// Schedules are read back according to their kind, as Job.UnmarshalJSON does, so jobs built with AfterEach
+// keep their fixed delay, while jobs scheduled with AfterJob can't be imported.
+// resolve is called with each job's name to re-attach its function; it may return nil
+// for jobs that should be registered without one.
+// Every definition is validated before any job is added, and the jobs are added together, so on error
+// the Manager is left unchanged, even with Add called concurrently.
+func (m *Manager) ImportJSON(data []byte, resolve func(name string) func(context.Context)) error {
+	var defs []jobDefinition
+	if err := json.Unmarshal(data, &defs); err != nil {
+		return fmt.Errorf("cron: invalid job definitions: %w", err)
+	}
+
+	seen := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		if seen[def.Name] {
+			return fmt.Errorf("cron: job %q already exists", def.Name)
+		}
+		seen[def.Name] = true
+	}
+
+	jobs := make([]*Job, len(defs))
+	for i, def := range defs {
+		j, err := restoreSchedule(def.Schedule)
+		if err != nil {
+			return fmt.Errorf("cron: job %q: %w", def.Name, err)
+		}
+		loc, err := time.LoadLocation(def.Timezone)
+		if err != nil {
+			return fmt.Errorf("cron: job %q has invalid timezone %q: %w", def.Name, def.Timezone, err)
+		}
+		j.Timezone = loc
+		j.Blocking = def.Blocking
+		if resolve != nil {
+			j.Fn = resolve(def.Name)
+		}
+		jobs[i] = j
+	}
+
+	// checked and added under one lock so a concurrent Add can't leave the import half done
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, def := range defs {
+		if _, ok := m.jobs[def.Name]; ok {
+			return fmt.Errorf("cron: job %q already exists", def.Name)
+		}
+	}
+	for i, j := range jobs {
+		// the jobs are new, so the names checked above are all that could fail
+		_ = m.add(defs[i].Name, j)
+	}
+	return nil
+}
+
+// Events returns a channel receiving an ExecutionEvent for every execution of every registered job.
+// Sends never block: if the subscriber falls behind and the buffer fills up, events are dropped
+// so a slow reader can't stall any job's scheduling loop.
+func (m *Manager) Events() <-chan ExecutionEvent {
+	return m.events
+}
+
+// publish delivers an event without blocking.
+func (m *Manager) publish(e ExecutionEvent) {
+	select {
+	case m.events <- e:
+	default:
+	}
+}
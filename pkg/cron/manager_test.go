@@ -0,0 +1,320 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestManagerAdd tests that names are unique and jobs can only have one Manager.
+func TestManagerAdd(t *testing.T) {
+	m := NewManager()
+	job := Schedule("*/5 * * * * *")
+
+	if err := m.Add("a", job); err != nil {
+		t.Fatalf("Add returned an error: %v", err)
+	}
+	if err := m.Add("a", Schedule("*/5 * * * * *")); err == nil {
+		t.Errorf("Add did not reject a duplicate name")
+	}
+	if err := NewManager().Add("b", job); err == nil {
+		t.Errorf("Add did not reject a job owned by another Manager")
+	}
+
+	m.Remove("a")
+	if _, ok := m.Job("a"); ok {
+		t.Errorf("Remove did not unregister the job")
+	}
+	if err := NewManager().Add("b", job); err != nil {
+		t.Errorf("Add rejected a job removed from its Manager: %v", err)
+	}
+}
+
+// TestManagerEvents tests that executions of every managed job reach the event channel.
+func TestManagerEvents(t *testing.T) {
+	m := NewManager()
+	noop := func(ctx context.Context) {}
+	m.Add("a", fastJob(10*time.Millisecond).Execute(noop))
+	m.Add("b", fastJob(10*time.Millisecond).Execute(noop))
+
+	m.StartAll()
+	defer m.StopAll()
+
+	seen := map[string]bool{}
+	deadline := time.After(time.Second)
+	for len(seen) < 2 {
+		select {
+		case e := <-m.Events():
+			if e.FireTime.IsZero() {
+				t.Errorf("event for %q has no fire time", e.Job)
+			}
+			seen[e.Job] = true
+		case <-deadline:
+			t.Fatalf("expected events from both jobs, got %v", seen)
+		}
+	}
+}
+
+// TestManagerExportImport tests that job definitions survive an export/import round trip.
+func TestManagerExportImport(t *testing.T) {
+	loc, _ := time.LoadLocation("America/New_York")
+	m := NewManager()
+	m.Add("report", Schedule("0 9 * * 1-5").SetTimezone(loc))
+	m.Add("cleanup", Schedule("*/30 * * * * *").SetBlocking(true))
+	m.Add("poll", AfterEach(time.Second))
+
+	data, err := m.ExportJSON()
+	if err != nil {
+		t.Fatalf("ExportJSON returned an error: %v", err)
+	}
+
+	restored := NewManager()
+	resolved := map[string]bool{}
+	err = restored.ImportJSON(data, func(name string) func(context.Context) {
+		resolved[name] = true
+		return func(ctx context.Context) {}
+	})
+	if err != nil {
+		t.Fatalf("ImportJSON returned an error: %v", err)
+	}
+
+	report, ok := restored.Job("report")
+	if !ok || report.scheduleStr != "0 9 * * 1-5" || report.Timezone.String() != "America/New_York" {
+		t.Errorf("report job was not restored correctly")
+	}
+	cleanup, ok := restored.Job("cleanup")
+	if !ok || !cleanup.Blocking || cleanup.Fn == nil {
+		t.Errorf("cleanup job was not restored correctly")
+	}
+	poll, ok := restored.Job("poll")
+	if !ok || poll.ScheduleString() != "@after-each 1s" || !poll.fixedDelay {
+		t.Errorf("poll job was not restored as a fixed-delay job")
+	}
+	if !resolved["report"] || !resolved["cleanup"] || !resolved["poll"] {
+		t.Errorf("resolve was not called for every job, got %v", resolved)
+	}
+
+	// importing again collides with the existing names and must not add anything
+	if err := restored.ImportJSON(data, nil); err == nil {
+		t.Errorf("ImportJSON accepted duplicate names")
+	}
+	if err := NewManager().ImportJSON([]byte(`[{"name":"x","schedule":"bad"}]`), nil); err == nil {
+		t.Errorf("ImportJSON accepted an invalid schedule")
+	}
+
+	// an Add racing the import either loses or makes the whole import fail
+	for i := 0; i < 100; i++ {
+		m := NewManager()
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Add("poll", Schedule("@daily"))
+		}()
+		err := m.ImportJSON(data, nil)
+		wg.Wait()
+		if names := m.Names(); err == nil && len(names) != 3 || err != nil && len(names) != 1 {
+			t.Fatalf("ImportJSON returned %v and left %v registered", err, names)
+		}
+	}
+}
+
+// TestManagerGlobalConcurrency tests that the global cap bounds executions across jobs.
+func TestManagerGlobalConcurrency(t *testing.T) {
+	var mutex sync.Mutex
+	var active, peak int
+	task := func(ctx context.Context) {
+		mutex.Lock()
+		active++
+		if active > peak {
+			peak = active
+		}
+		mutex.Unlock()
+		time.Sleep(30 * time.Millisecond)
+		mutex.Lock()
+		active--
+		mutex.Unlock()
+	}
+
+	m := NewManager()
+	m.SetGlobalConcurrency(2)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		m.Add(name, fastJob(10*time.Millisecond).Execute(task))
+	}
+	m.Add("skipper", fastJob(10*time.Millisecond).SetLimitPolicy(LimitSkip).Execute(task))
+
+	m.StartAll()
+	time.Sleep(200 * time.Millisecond)
+	m.StopAll()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if peak > 2 {
+		t.Errorf("expected at most 2 concurrent executions, saw %d", peak)
+	}
+	if peak == 0 {
+		t.Errorf("expected jobs to run")
+	}
+}
+
+// TestManagerShutdownAndSnapshot tests that shutdown waits for in-flight runs and snapshots state.
+func TestManagerShutdownAndSnapshot(t *testing.T) {
+	started := make(chan struct{})
+	var finished int32
+	m := NewManager()
+	m.Add("slow", fastJob(10*time.Millisecond).Execute(func(ctx context.Context) {
+		select {
+		case started <- struct{}{}:
+		default:
+			return
+		}
+		time.Sleep(30 * time.Millisecond)
+		atomic.StoreInt32(&finished, 1)
+	}))
+
+	m.StartAll()
+	<-started
+	states, err := m.ShutdownAndSnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("ShutdownAndSnapshot returned an error: %v", err)
+	}
+	if atomic.LoadInt32(&finished) != 1 {
+		t.Errorf("ShutdownAndSnapshot returned before the in-flight run finished")
+	}
+	state := states["slow"]
+	if state.RunCount == 0 || state.LastRun.IsZero() {
+		t.Errorf("snapshot is missing run state: %+v", state)
+	}
+
+	restored := NewManager()
+	restored.Add("slow", Schedule("* * * * * *"))
+	restored.RestoreState(states)
+	if j, _ := restored.Job("slow"); j.State() != state {
+		t.Errorf("RestoreState loaded %+v, want %+v", j.State(), state)
+	}
+}
+
+// TestManagerValidate tests that Validate reports jobs missing a function or never firing.
+func TestManagerValidate(t *testing.T) {
+	m := NewManager()
+	ok := Schedule("* * * * *").Execute(func(ctx context.Context) {})
+	m.Add("ok", ok)
+	// the job it follows hasn't run yet, but will
+	m.Add("follower", Schedule("* * * * *").AfterJob(ok, time.Minute).Execute(func(ctx context.Context) {}))
+	if err := m.Validate(); err != nil {
+		t.Errorf("Validate rejected a valid Manager: %v", err)
+	}
+
+	never := Schedule("0 0 30 2 *").Execute(func(ctx context.Context) {})
+	m.Add("nofn", Schedule("* * * * *"))
+	m.Add("never", never)
+	m.Add("stranded", Schedule("* * * * *").AfterJob(never, time.Minute).Execute(func(ctx context.Context) {}))
+	err := m.Validate()
+	if err == nil {
+		t.Fatalf("Validate accepted broken jobs")
+	}
+	want := `cron: invalid jobs: "never" never fires; "nofn" has no function; "stranded" never fires`
+	if err.Error() != want {
+		t.Errorf("Validate returned %q, want %q", err, want)
+	}
+}
+
+// TestManagerFindCollisions tests that jobs firing at the same instant are paired up.
+func TestManagerFindCollisions(t *testing.T) {
+	m := NewManager()
+	m.Add("hourly", Schedule("0 * * * *"))
+	m.Add("quarter", Schedule("*/15 * * * *"))
+	m.Add("offset", Schedule("7 * * * *"))
+	// half a day away, so it's only inside the longer horizon
+	m.Add("daily", Schedule(fmt.Sprintf("0 %d * * *", (time.Now().UTC().Hour()+12)%24)))
+
+	got := m.FindCollisions(2 * time.Hour)
+	want := [][2]string{{"hourly", "quarter"}}
+	if len(got) != len(want) || got[0] != want[0] {
+		t.Errorf("FindCollisions(2h) returned %v, want %v", got, want)
+	}
+
+	got = m.FindCollisions(48 * time.Hour)
+	want = [][2]string{{"daily", "hourly"}, {"daily", "quarter"}, {"hourly", "quarter"}}
+	if len(got) != len(want) {
+		t.Fatalf("FindCollisions(48h) returned %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("FindCollisions(48h) returned %v, want %v", got, want)
+			break
+		}
+	}
+
+	// intervals with the same period don't collide unless they were started together
+	m = NewManager()
+	first := Every(time.Minute).Execute(func(ctx context.Context) {})
+	second := AfterEach(time.Minute).Execute(func(ctx context.Context) {})
+	m.Add("first", first)
+	m.Add("second", second)
+	if got := m.FindCollisions(time.Hour); len(got) != 0 {
+		t.Errorf("expected intervals that aren't running to be left out, got %v", got)
+	}
+	first.Start()
+	defer first.Stop()
+	time.Sleep(5 * time.Millisecond)
+	second.Start()
+	defer second.Stop()
+	if got := m.FindCollisions(time.Hour); len(got) != 0 {
+		t.Errorf("expected intervals started apart not to collide, got %v", got)
+	}
+}
+
+// TestManagerOnDrop tests that ticks skipped by a saturated limit reach OnDrop.
+func TestManagerOnDrop(t *testing.T) {
+	release := make(chan struct{})
+	dropped := make(chan time.Time, 1)
+
+	m := NewManager()
+	m.SetGlobalConcurrency(1)
+	m.Add("hog", fastJob(5*time.Millisecond).Execute(func(ctx context.Context) {
+		<-release
+	}))
+	m.Add("dropper", fastJob(10*time.Millisecond).SetLimitPolicy(LimitSkip).OnDrop(func(scheduled time.Time) {
+		select {
+		case dropped <- scheduled:
+		default:
+		}
+	}).Execute(func(ctx context.Context) {}))
+
+	m.StartAll()
+	defer m.StopAll()
+	defer close(release)
+
+	select {
+	case scheduled := <-dropped:
+		if scheduled.IsZero() {
+			t.Errorf("OnDrop received a zero scheduled time")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected a tick to be dropped while the limit was saturated")
+	}
+}
+
+// TestManagerRunOnStart tests that StartAll honors RunOnStart for jobs on the shared loop.
+func TestManagerRunOnStart(t *testing.T) {
+	ran := make(chan struct{}, 2)
+	m := NewManager()
+	for _, blocking := range []bool{true, false} {
+		m.Add(fmt.Sprint(blocking), Schedule("0 0 1 1 *").SetBlocking(blocking).RunOnStart(true).Execute(func(ctx context.Context) {
+			ran <- struct{}{}
+		}))
+	}
+	m.StartAll()
+	defer m.StopAll()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-ran:
+		case <-time.After(time.Second):
+			t.Fatalf("expected both jobs to run on StartAll, got %d", i)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package cron
+
+import "time"
+
+// Metrics receives counters and timings for a Job's runs, keyed by the Job's Name.
+// Implementations adapt it to a metrics backend, for example Prometheus counters and histograms,
+// without this package depending on one. Methods may be called from several goroutines at once.
+type Metrics interface {
+	// IncRun counts a run of the Job's function.
+	IncRun(name string)
+	// IncError counts a run that returned an error or panicked.
+	IncError(name string)
+	// IncSkip counts a tick that did not run the function, for any of the reasons passed to OnSkip.
+	IncSkip(name string)
+	// ObserveDuration records how long a run took.
+	ObserveDuration(name string, d time.Duration)
+}
+
+// nopMetrics discards everything, it stands in when no Metrics is set.
+type nopMetrics struct{}
+
+func (nopMetrics) IncRun(string)                         {}
+func (nopMetrics) IncError(string)                       {}
+func (nopMetrics) IncSkip(string)                        {}
+func (nopMetrics) ObserveDuration(string, time.Duration) {}
+
+// WithMetrics makes the Job report each run, its duration and whether it failed, along with skipped ticks, to m.
+// They are reported under the Job's Name. Passing nil stops the reporting.
+func (j *Job) WithMetrics(m Metrics) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.metrics = m
+	return j
+}
+
+// meter returns the Job's Metrics, or one discarding everything. The caller holds the mutex.
+func (j *Job) meter() Metrics {
+	if j.metrics == nil {
+		return nopMetrics{}
+	}
+	return j.metrics
+}
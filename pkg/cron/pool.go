@@ -0,0 +1,98 @@
+package cron
+
+import (
+	"context"
+	"sync"
+)
+
+// poolTask is a run waiting in a workerPool; drop is called instead of run if the pool never gets to it.
+type poolTask struct {
+	run  func()
+	drop func()
+}
+
+// workerPool runs the non-blocking executions of many jobs on a fixed number of goroutines,
+// see Scheduler.WithMaxConcurrency.
+type workerPool struct {
+	tasks  chan poolTask
+	policy LimitPolicy
+	// closing ends the wait of submitters, closed turns new ones away and done makes the workers drain
+	// the queue; shutdown sets them in that order so no task can be queued after the drain
+	closing chan struct{}
+	closed  bool
+	done    chan struct{}
+	mutex   sync.RWMutex
+	wg      sync.WaitGroup
+}
+
+// newWorkerPool starts n workers sharing a queue of up to n waiting tasks.
+func newWorkerPool(n int, policy LimitPolicy) *workerPool {
+	p := &workerPool{
+		tasks:   make(chan poolTask, n),
+		policy:  policy,
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	p.wg.Add(n)
+	for i := 0; i < n; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// work runs tasks until the pool shuts down, then drops whatever is still queued.
+func (p *workerPool) work() {
+	defer p.wg.Done()
+	for {
+		select {
+		case t := <-p.tasks:
+			t.run()
+		case <-p.done:
+			for {
+				select {
+				case t := <-p.tasks:
+					t.drop()
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// submit queues a task, and reports whether it was queued. When the queue is full it gives up
+// under LimitSkip or if wait is false, and otherwise waits for room until ctx is done or the pool shuts down.
+// A task that isn't queued is not dropped, that is left to the caller.
+func (p *workerPool) submit(ctx context.Context, t poolTask, wait bool) bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.closed {
+		return false
+	}
+	if p.policy == LimitSkip || !wait {
+		select {
+		case p.tasks <- t:
+			return true
+		default:
+			return false
+		}
+	}
+	select {
+	case p.tasks <- t:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-p.closing:
+		return false
+	}
+}
+
+// shutdown stops the workers once their current tasks return, dropping queued ones.
+func (p *workerPool) shutdown() {
+	close(p.closing)
+	p.mutex.Lock()
+	p.closed = true
+	p.mutex.Unlock()
+	close(p.done)
+	p.wg.Wait()
+}
@@ -0,0 +1,241 @@
+package cron
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	_cron "github.com/robfig/cron/v3"
+)
+
+// intervalSchedule fires a fixed duration after the time it is given.
+type intervalSchedule time.Duration
+
+// Next returns t plus the interval.
+func (d intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(d))
+}
+
+// floating reports whether a schedule counts from whenever it is started rather than following the clock,
+// which is the case for intervals and unions including one.
+func floating(schedule _cron.Schedule) bool {
+	switch s := schedule.(type) {
+	case intervalSchedule:
+		return true
+	case unionSchedule:
+		for _, part := range s {
+			if floating(part) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// afterJobSchedule fires a delay after another job last ran, see Job.AfterJob.
+type afterJobSchedule struct {
+	other *Job
+	delay time.Duration
+}
+
+// Next returns the other job's last run plus the delay,
+// or the zero time if the other job hasn't run or the target is not after t.
+func (s afterJobSchedule) Next(t time.Time) time.Time {
+	s.other.mutex.RLock()
+	lastRun := s.other.lastRun
+	s.other.mutex.RUnlock()
+	if lastRun.IsZero() {
+		return time.Time{}
+	}
+	target := lastRun.Add(s.delay)
+	if !target.After(t) {
+		return time.Time{}
+	}
+	return target.In(t.Location())
+}
+
+// unionSchedule fires whenever any of its schedules does, see Schedules.
+type unionSchedule []_cron.Schedule
+
+// Next returns the earliest of the schedules' next times after t, so schedules firing
+// at the same instant fire once, or the zero time if none fires again.
+func (u unionSchedule) Next(t time.Time) time.Time {
+	var next time.Time
+	for _, schedule := range u {
+		if at := schedule.Next(t); !at.IsZero() && (next.IsZero() || at.Before(next)) {
+			next = at
+		}
+	}
+	return next
+}
+
+// parseUnion parses schedule strings separated by semicolons into a unionSchedule.
+func parseUnion(scheduleStr string) (_cron.Schedule, error) {
+	var union unionSchedule
+	for _, part := range strings.Split(scheduleStr, ";") {
+		schedule, err := parse(part)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", strings.TrimSpace(part), err)
+		}
+		union = append(union, schedule)
+	}
+	return union, nil
+}
+
+// millisecondSchedule extends a seconds-level cron schedule with a leading milliseconds field.
+type millisecondSchedule struct {
+	// millis holds the allowed milliseconds of a second in ascending order
+	millis  []int
+	seconds _cron.Schedule
+}
+
+// Next returns the first allowed millisecond after t within a second matched by the seconds schedule.
+func (s millisecondSchedule) Next(t time.Time) time.Time {
+	// candidates start at the next whole millisecond strictly after t
+	candidate := t.Truncate(time.Millisecond).Add(time.Millisecond)
+	second := candidate.Truncate(time.Second)
+	// robfig schedules return the first whole second after their argument, so this asks whether second itself matches
+	if s.seconds.Next(second.Add(-time.Nanosecond)).Equal(second) {
+		offset := int(candidate.Sub(second) / time.Millisecond)
+		for _, ms := range s.millis {
+			if ms >= offset {
+				return second.Add(time.Duration(ms) * time.Millisecond)
+			}
+		}
+	}
+	next := s.seconds.Next(second)
+	if next.IsZero() {
+		return next
+	}
+	return next.Add(time.Duration(s.millis[0]) * time.Millisecond)
+}
+
+// parseMillisecond parses a 7-field expression whose leading field is milliseconds (0-999),
+// followed by the usual seconds, minutes, hours, day of month, month and day of week fields.
+// zone holds the expression's leading timezone, if it has one.
+func parseMillisecond(zone, fields []string) (_cron.Schedule, error) {
+	millis, err := parseMillis(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("milliseconds field %q (valid range 0-999): %w", fields[0], err)
+	}
+	rest := strings.Join(fields[1:], " ")
+	seconds, err := parser.Parse(strings.Join(append(zone, rest), " "))
+	if err != nil {
+		return nil, fieldError(rest, err)
+	}
+	return millisecondSchedule{millis: millis, seconds: withWallClock(seconds)}, nil
+}
+
+// parseMillis expands a milliseconds field made of comma separated "*", "n", "a-b" items,
+// each optionally followed by a "/step", into the sorted milliseconds it allows.
+func parseMillis(field string) ([]int, error) {
+	allowed := make(map[int]bool)
+	for _, item := range strings.Split(field, ",") {
+		rangePart, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", item)
+			}
+			rangePart, step = item[:i], n
+		}
+
+		low, high := 0, 999
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("invalid range %q", item)
+			}
+			if high, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("invalid range %q", item)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", item)
+			}
+			low = n
+			// like cron, "n/step" runs from n to the maximum while a bare "n" is just n
+			if step == 1 {
+				high = n
+			}
+		}
+		if low < 0 || high > 999 || low > high {
+			return nil, fmt.Errorf("%q out of range", item)
+		}
+		for ms := low; ms <= high; ms += step {
+			allowed[ms] = true
+		}
+	}
+
+	millis := make([]int, 0, len(allowed))
+	for ms := range allowed {
+		millis = append(millis, ms)
+	}
+	sort.Ints(millis)
+	return millis, nil
+}
+
+// allHours is the SpecSchedule hour mask of a schedule that fires every hour.
+const allHours = 1<<24 - 1
+
+// wallClockSchedule evaluates a schedule with fixed hours on the local wall clock rather than
+// on elapsed time, which settles what happens when daylight saving time changes the clocks:
+//   - a time skipped when clocks spring forward, such as 02:30, fires as soon as clocks have jumped
+//     past it, at the same distance into the new time (03:30), so the occurrence isn't lost;
+//   - a time repeated when clocks fall back, such as 01:30, fires only on its first occurrence.
+//
+// Schedules that fire every hour are left to robfig, which keeps them firing once per elapsed hour.
+type wallClockSchedule struct {
+	spec *_cron.SpecSchedule
+}
+
+// withWallClock wraps schedules with fixed hours that are evaluated in the location of the times they're given.
+func withWallClock(schedule _cron.Schedule) _cron.Schedule {
+	spec, ok := schedule.(*_cron.SpecSchedule)
+	if !ok || spec.Location != time.Local || spec.Hour&allHours == allHours {
+		return schedule
+	}
+	return wallClockSchedule{spec: spec}
+}
+
+// Next walks the wall clock from t's local time and maps each match back to an instant in t's location,
+// until reaching one after t.
+func (s wallClockSchedule) Next(t time.Time) time.Time {
+	wall := asWallClock(t)
+	for {
+		if wall = s.spec.Next(wall); wall.IsZero() {
+			return wall
+		}
+		if next := fromWallClock(wall, t.Location()); next.After(t) {
+			return next
+		}
+	}
+}
+
+// MarshalJSON writes the wrapped schedule, so Job.MarshalJSON's output doesn't depend on the wrapping.
+func (s wallClockSchedule) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.spec)
+}
+
+// asWallClock returns t's local date and time as the same reading in UTC, where days have no gaps or repeats.
+func asWallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// fromWallClock returns the instant a wall clock in loc reads wall, taking the first of repeated readings
+// and moving readings that don't exist forward by the size of the gap.
+func fromWallClock(wall time.Time, loc *time.Location) time.Time {
+	t := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), loc)
+	// time.Date resolves readings inside a gap with the offset from before it, landing earlier than asked
+	if short := wall.Sub(asWallClock(t)); short > 0 {
+		t = t.Add(short)
+	}
+	return t
+}
@@ -0,0 +1,412 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scheduler runs a collection of jobs under a single lifecycle: starting the Scheduler starts
+// every job added to it, and stopping it stops them all.
+// Jobs are identified by the id Add returns.
+type Scheduler struct {
+	jobs   map[int]*Job
+	nextID int
+	// running is whether Start was called without a matching Stop
+	running bool
+	// shared selects the heap engine, which is non-nil while running
+	shared bool
+	engine *heapEngine
+	// maxConcurrency sizes the worker pool, which is non-nil while running if it is positive
+	maxConcurrency int
+	poolPolicy     LimitPolicy
+	pool           *workerPool
+	mutex          sync.Mutex
+}
+
+// ScheduledRun is an upcoming run of one of a Scheduler's jobs, see Upcoming.
+type ScheduledRun struct {
+	ID   int       `json:"id"`
+	Name string    `json:"name,omitempty"`
+	At   time.Time `json:"at"`
+}
+
+// NewScheduler returns an empty Scheduler.
+func NewScheduler() *Scheduler {
+	return &Scheduler{jobs: make(map[int]*Job)}
+}
+
+// WithSharedTimer makes the Scheduler drive all of its jobs from one goroutine and timer,
+// sleeping until the earliest upcoming fire time, instead of each job running its own loop.
+// This keeps hundreds or thousands of jobs cheap. It takes effect at the next Start.
+func (s *Scheduler) WithSharedTimer() *Scheduler {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.shared = true
+	return s
+}
+
+// WithMaxConcurrency runs the executions of all non-blocking jobs on a pool of n goroutines shared
+// by the Scheduler, rather than on a new goroutine per tick, so fast schedules with slow functions can't
+// pile up goroutines without bound. Up to n more executions wait in a queue; once it is full, policy
+// decides whether a tick waits for room (LimitQueue) or is dropped (LimitSkip), either way reporting
+// dropped ticks to OnDrop and as a skip to the job's Metrics. With WithSharedTimer ticks are always dropped
+// when the queue is full, since waiting would hold up every other job on the timer. Blocking jobs are unaffected.
+// A value of zero or less removes the pool. It takes effect at the next Start.
+func (s *Scheduler) WithMaxConcurrency(n int, policy LimitPolicy) *Scheduler {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.maxConcurrency = n
+	s.poolPolicy = policy
+	return s
+}
+
+// Add registers a job and returns its id. If the Scheduler is running, the job is started straight away.
+func (s *Scheduler) Add(j *Job) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.nextID++
+	s.jobs[s.nextID] = j
+	if s.running {
+		s.start(j)
+	}
+	return s.nextID
+}
+
+// Remove stops the job with the given id and unregisters it. Unknown ids are ignored.
+func (s *Scheduler) Remove(id int) {
+	s.mutex.Lock()
+	j, ok := s.jobs[id]
+	delete(s.jobs, id)
+	s.mutex.Unlock()
+	if ok {
+		j.Stop()
+		j.setPool(nil)
+	}
+}
+
+// ReconcileResult lists by name what Reconcile changed.
+type ReconcileResult struct {
+	Added     []string `json:"added,omitempty"`
+	Removed   []string `json:"removed,omitempty"`
+	Restarted []string `json:"restarted,omitempty"`
+	Unchanged []string `json:"unchanged,omitempty"`
+}
+
+// Reconcile brings the registered jobs in line with desired, matching them by Name, for example after reloading
+// configuration. Registered jobs missing from desired are stopped and removed, and new ones are added.
+// A job whose schedule or timezone changed, see Job.SameSchedule, is stopped and replaced by its desired
+// counterpart under the same id. Unchanged jobs are left running as they are, so they don't miss a run.
+// Added and replacement jobs are started if the Scheduler is running. Jobs without a name can't be matched,
+// so registered ones are left alone and desired ones are ignored; names in desired should be unique.
+func (s *Scheduler) Reconcile(desired []*Job) ReconcileResult {
+	var result ReconcileResult
+	var stale, fresh []*Job
+
+	s.mutex.Lock()
+	ids := make(map[string]int, len(s.jobs))
+	for id, j := range s.jobs {
+		if name := j.name(); name != "" {
+			ids[name] = id
+		}
+	}
+	wanted := make(map[string]bool, len(desired))
+	for _, j := range desired {
+		name := j.name()
+		if name == "" {
+			continue
+		}
+		wanted[name] = true
+		id, ok := ids[name]
+		switch {
+		case !ok:
+			s.nextID++
+			s.jobs[s.nextID] = j
+			fresh = append(fresh, j)
+			result.Added = append(result.Added, name)
+		case s.jobs[id].SameSchedule(j):
+			result.Unchanged = append(result.Unchanged, name)
+		default:
+			stale = append(stale, s.jobs[id])
+			s.jobs[id] = j
+			fresh = append(fresh, j)
+			result.Restarted = append(result.Restarted, name)
+		}
+	}
+	for name, id := range ids {
+		if !wanted[name] {
+			stale = append(stale, s.jobs[id])
+			delete(s.jobs, id)
+			result.Removed = append(result.Removed, name)
+		}
+	}
+	s.mutex.Unlock()
+
+	// stopped outside the lock, like Remove does, since RunOnStop may run the function
+	for _, j := range stale {
+		j.Stop()
+		j.setPool(nil)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.running {
+		registered := make(map[*Job]bool, len(s.jobs))
+		for _, j := range s.jobs {
+			registered[j] = true
+		}
+		for _, j := range fresh {
+			// skipping any removed in the meantime
+			if registered[j] {
+				s.start(j)
+			}
+		}
+	}
+	sort.Strings(result.Removed)
+	return result
+}
+
+// Job returns the job registered under the given id.
+func (s *Scheduler) Job(id int) (*Job, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	j, ok := s.jobs[id]
+	return j, ok
+}
+
+// IDs returns the ids of all registered jobs in ascending order.
+func (s *Scheduler) IDs() []int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.sortedIDs()
+}
+
+// sortedIDs returns the ids of all registered jobs in ascending order. The caller holds the mutex.
+func (s *Scheduler) sortedIDs() []int {
+	ids := make([]int, 0, len(s.jobs))
+	for id := range s.jobs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// Start starts every registered job, and every job added until Stop is called.
+func (s *Scheduler) Start() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.running = true
+	if s.shared && s.engine == nil {
+		s.engine = newHeapEngine()
+	}
+	if s.maxConcurrency > 0 && s.pool == nil {
+		s.pool = newWorkerPool(s.maxConcurrency, s.poolPolicy)
+	}
+	for _, j := range s.jobs {
+		s.start(j)
+	}
+}
+
+// start starts a job on its own loop or hands it to the shared engine. The caller holds the mutex.
+func (s *Scheduler) start(j *Job) {
+	j.setPool(s.pool)
+	if s.engine == nil {
+		j.Start()
+		return
+	}
+	if done, _ := j.begin(nil); done != nil {
+		s.engine.add(j, done)
+	}
+}
+
+// Stop stops every registered job, cancelling their contexts, and blocks until
+// the runs of blocking jobs that were in progress have returned. Executions running on the pool set up by
+// WithMaxConcurrency are waited for as well, and those still queued are dropped. Running jobs set up with RunOnStop
+// make their final run, off the pool, and Stop waits for it to return; its context times out after
+// 30 seconds. The jobs stay registered and are started again by the next Start.
+func (s *Scheduler) Stop() {
+	_ = s.Shutdown(context.Background())
+}
+
+// Shutdown is Stop with a deadline, for graceful shutdown on SIGTERM in the manner of http.Server.Shutdown:
+// it stops every registered job, cancelling their contexts, then waits for runs in progress like Stop does
+// until ctx is done. Running jobs set up with RunOnStop start their final run, concurrently and off the pool,
+// and its context is cancelled once ctx is done or RunOnStop's 30 seconds are up, whichever comes first;
+// a final run still going when ctx is done is abandoned like any other late run. If runs outlast ctx,
+// it returns an error wrapping ctx.Err() that names the jobs still running, by Name or else by id,
+// and leaves them to finish on their own.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.mutex.Lock()
+	s.running = false
+	ids := s.sortedIDs()
+	jobs := make([]*Job, len(ids))
+	for i, id := range ids {
+		jobs[i] = s.jobs[id]
+	}
+	engine, pool := s.engine, s.pool
+	s.engine, s.pool = nil, nil
+	s.mutex.Unlock()
+
+	// the final runs of RunOnStop are made concurrently, each bound to ctx
+	finals := make([]chan struct{}, len(jobs))
+	for i, j := range jobs {
+		if !j.halt() {
+			continue
+		}
+		finished := make(chan struct{})
+		finals[i] = finished
+		go func(j *Job) {
+			defer close(finished)
+			j.finalRun(ctx)
+		}(j)
+	}
+	if engine != nil {
+		engine.shutdown()
+	}
+	var late []string
+	if pool != nil {
+		drained := make(chan struct{})
+		go func() {
+			pool.shutdown()
+			close(drained)
+		}()
+		if !closedBy(ctx, drained) {
+			late = append(late, "runs on the worker pool")
+		}
+		detach(ctx, engine, jobs)
+	}
+	for i, j := range jobs {
+		finished := finals[i] == nil || closedBy(ctx, finals[i])
+		if finished && (!j.blocking() || j.inflight.wait(ctx) == nil) {
+			continue
+		}
+		if name := j.name(); name != "" {
+			late = append(late, strconv.Quote(name))
+		} else {
+			late = append(late, "job "+strconv.Itoa(ids[i]))
+		}
+	}
+	if len(late) > 0 {
+		return fmt.Errorf("cron: shutdown left %s running: %w", strings.Join(late, ", "), ctx.Err())
+	}
+	return nil
+}
+
+// detach takes jobs off the worker pool they were stopped with. They keep it until their scheduling loops,
+// or the engine driving them, have returned, so ticks already under way are dropped by the closed pool
+// rather than run unbounded; after that Trigger and a standalone Start run them on their own goroutines,
+// and the next Start of the Scheduler hands them a fresh pool. Jobs still going when ctx is done are
+// taken off it all the same.
+func detach(ctx context.Context, engine *heapEngine, jobs []*Job) {
+	if engine != nil {
+		closedBy(ctx, engine.exited)
+	}
+	for _, j := range jobs {
+		j.mutex.RLock()
+		exited := j.exited
+		j.mutex.RUnlock()
+		if exited != nil {
+			closedBy(ctx, exited)
+		}
+		j.setPool(nil)
+	}
+}
+
+// closedBy waits for ch to close until ctx is done, and reports whether it did, counting a close
+// that happened just in time.
+func closedBy(ctx context.Context, ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	case <-ctx.Done():
+		select {
+		case <-ch:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// Upcoming returns the running jobs whose next fire time falls within the given window from now,
+// sorted by that time, for example to answer what runs in the next ten minutes.
+// The fire times are the ones the jobs are armed for, see Job.ArmedFireTime. The jobs are not disturbed.
+func (s *Scheduler) Upcoming(within time.Duration) []ScheduledRun {
+	s.mutex.Lock()
+	jobs := make(map[int]*Job, len(s.jobs))
+	for id, j := range s.jobs {
+		jobs[id] = j
+	}
+	s.mutex.Unlock()
+
+	horizon := time.Now().Add(within)
+	var runs []ScheduledRun
+	for id, j := range jobs {
+		at, ok := j.ArmedFireTime()
+		if !ok || at.After(horizon) {
+			continue
+		}
+		j.mutex.RLock()
+		name := j.Name
+		j.mutex.RUnlock()
+		runs = append(runs, ScheduledRun{ID: id, Name: name, At: at})
+	}
+	sort.Slice(runs, func(a, b int) bool {
+		if !runs[a].At.Equal(runs[b].At) {
+			return runs[a].At.Before(runs[b].At)
+		}
+		return runs[a].ID < runs[b].ID
+	})
+	return runs
+}
+
+// savedJob is the serialized form of a registered job used by SaveState and LoadState.
+type savedJob struct {
+	Job   *Job     `json:"job"`
+	State JobState `json:"state"`
+}
+
+// SaveState writes every registered job to w as JSON, in ascending id order: its definition as written by
+// Job.MarshalJSON, including its name, schedule and timezone, along with its runtime state such as its last run.
+// Read it back with LoadState after a restart. Functions can't be serialized and are left out.
+func (s *Scheduler) SaveState(w io.Writer) error {
+	s.mutex.Lock()
+	saved := make([]savedJob, 0, len(s.jobs))
+	for _, id := range s.sortedIDs() {
+		saved = append(saved, savedJob{Job: s.jobs[id]})
+	}
+	s.mutex.Unlock()
+
+	for i := range saved {
+		saved[i].State = saved[i].Job.State()
+	}
+	return json.NewEncoder(w).Encode(saved)
+}
+
+// LoadState returns a new, stopped Scheduler holding the jobs saved by SaveState, in the same order and with
+// their runtime state restored, so CatchUp can make up for runs missed in between. fns re-binds each job's
+// function by name; jobs missing from it are registered without one and must be given one with Execute
+// before they can start. Jobs scheduled with AfterJob can't be restored.
+func LoadState(r io.Reader, fns map[string]func(ctx context.Context)) (*Scheduler, error) {
+	var saved []savedJob
+	if err := json.NewDecoder(r).Decode(&saved); err != nil {
+		return nil, fmt.Errorf("cron: invalid scheduler state: %w", err)
+	}
+	s := NewScheduler()
+	for _, entry := range saved {
+		if entry.Job == nil {
+			return nil, errors.New("cron: invalid scheduler state: missing job")
+		}
+		j := entry.Job.RestoreState(entry.State)
+		j.Execute(fns[j.name()])
+		s.Add(j)
+	}
+	return s, nil
+}
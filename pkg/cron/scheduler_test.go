@@ -0,0 +1,448 @@
+package cron
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestScheduler tests that a Scheduler starts, stops and removes its jobs together.
+func TestScheduler(t *testing.T) {
+	var runs int64
+	count := func(ctx context.Context) { atomic.AddInt64(&runs, 1) }
+
+	s := NewScheduler()
+	a := s.Add(fastJob(10 * time.Millisecond).Execute(count))
+	b := s.Add(fastJob(10 * time.Millisecond).Execute(count))
+	if a == b {
+		t.Fatalf("Add returned the same id twice")
+	}
+	jobA, _ := s.Job(a)
+	if jobA.IsRunning() {
+		t.Errorf("Add started a job before the Scheduler was started")
+	}
+
+	s.Start()
+	late := s.Add(fastJob(10 * time.Millisecond).Execute(count))
+	if j, _ := s.Job(late); !j.IsRunning() {
+		t.Errorf("Add did not start a job added to a running Scheduler")
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	s.Remove(b)
+	if _, ok := s.Job(b); ok {
+		t.Errorf("Remove did not unregister the job")
+	}
+	if ids := s.IDs(); len(ids) != 2 || ids[0] != a || ids[1] != late {
+		t.Errorf("expected ids %d and %d, got %v", a, late, ids)
+	}
+
+	s.Stop()
+	for _, id := range s.IDs() {
+		if j, _ := s.Job(id); j.IsRunning() {
+			t.Errorf("Stop left job %d running", id)
+		}
+	}
+	if atomic.LoadInt64(&runs) == 0 {
+		t.Errorf("expected the jobs to run while the Scheduler was started")
+	}
+}
+
+// TestSchedulerStopWaits tests that Stop returns only once in-flight blocking runs have.
+func TestSchedulerStopWaits(t *testing.T) {
+	started := make(chan struct{}, 1)
+	var finished int64
+	s := NewScheduler()
+	s.Add(fastJob(5 * time.Millisecond).SetBlocking(true).Execute(func(ctx context.Context) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		time.Sleep(50 * time.Millisecond)
+		atomic.StoreInt64(&finished, 1)
+	}))
+
+	s.Start()
+	<-started
+	s.Stop()
+	if atomic.LoadInt64(&finished) != 1 {
+		t.Errorf("Stop returned before the blocking run finished")
+	}
+}
+
+// TestSchedulerShutdown tests that Shutdown gives in-flight runs until its deadline and names the ones that overran it.
+func TestSchedulerShutdown(t *testing.T) {
+	started := make(chan struct{}, 2)
+	run := func(d time.Duration) func(ctx context.Context) {
+		return func(ctx context.Context) {
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+			time.Sleep(d)
+		}
+	}
+	s := NewScheduler()
+	s.Add(fastJob(5 * time.Millisecond).SetName("slow").SetBlocking(true).Execute(run(200 * time.Millisecond)))
+	s.Add(fastJob(5 * time.Millisecond).SetName("quick").SetBlocking(true).Execute(run(time.Millisecond)))
+	s.Start()
+	<-started
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := s.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), `"slow"`) || strings.Contains(err.Error(), "quick") {
+		t.Errorf("expected the deadline error to name only the slow job, got %v", err)
+	}
+	for _, id := range s.IDs() {
+		if j, _ := s.Job(id); j.IsRunning() {
+			t.Errorf("Shutdown left job %d running", id)
+		}
+	}
+
+	s.Start()
+	time.Sleep(20 * time.Millisecond)
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Errorf("expected Shutdown without a deadline to succeed, got %v", err)
+	}
+
+	// the final run of RunOnStop is bound to the deadline too
+	finalErr := make(chan error, 1)
+	s = NewScheduler()
+	s.Add(Schedule("0 0 1 1 *").SetName("flush").RunOnStop().Execute(func(ctx context.Context) {
+		time.Sleep(300 * time.Millisecond)
+		finalErr <- ctx.Err()
+	}))
+	s.Start()
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	began := time.Now()
+	err = s.Shutdown(ctx)
+	if elapsed := time.Since(began); elapsed > 250*time.Millisecond {
+		t.Errorf("expected Shutdown to return at its deadline, took %v", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), `"flush"`) {
+		t.Errorf("expected the deadline error to name the job making its final run, got %v", err)
+	}
+	if err := <-finalErr; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the final run's context to end with Shutdown's, got %v", err)
+	}
+}
+
+// TestSchedulerMaxConcurrency tests that non-blocking runs share a bounded pool and that ticks are dropped once it is full.
+func TestSchedulerMaxConcurrency(t *testing.T) {
+	var active, peak, runs, drops int64
+	slow := func(ctx context.Context) {
+		n := atomic.AddInt64(&active, 1)
+		for {
+			p := atomic.LoadInt64(&peak)
+			if n <= p || atomic.CompareAndSwapInt64(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		atomic.AddInt64(&active, -1)
+		atomic.AddInt64(&runs, 1)
+	}
+	metrics := &recordingMetrics{}
+	dropped := func(time.Time) { atomic.AddInt64(&drops, 1) }
+
+	s := NewScheduler().WithMaxConcurrency(2, LimitSkip)
+	s.Add(fastJob(5 * time.Millisecond).SetName("pooled").Execute(slow).OnDrop(dropped).WithMetrics(metrics))
+	s.Add(fastJob(5 * time.Millisecond).SetName("pooled").Execute(slow).OnDrop(dropped).WithMetrics(metrics))
+	s.Start()
+	time.Sleep(150 * time.Millisecond)
+	s.Stop()
+
+	if p := atomic.LoadInt64(&peak); p > 2 {
+		t.Errorf("expected at most 2 concurrent runs, got %d", p)
+	}
+	if atomic.LoadInt64(&runs) == 0 {
+		t.Errorf("expected runs on the pool")
+	}
+	if atomic.LoadInt64(&drops) == 0 {
+		t.Errorf("expected ticks to be dropped while the pool was full")
+	}
+	metrics.mutex.Lock()
+	skips := metrics.counts["skip pooled"]
+	metrics.mutex.Unlock()
+	if int64(skips) != atomic.LoadInt64(&drops) {
+		t.Errorf("expected a skip metric per drop, got %d skips for %d drops", skips, atomic.LoadInt64(&drops))
+	}
+	if n := atomic.LoadInt64(&active); n != 0 {
+		t.Errorf("Stop returned with %d runs still active", n)
+	}
+
+	// once stopped, the jobs are off the closed pool and run on their own goroutines again
+	j, _ := s.Job(s.IDs()[0])
+	before := atomic.LoadInt64(&runs)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	j.Trigger()
+	if err := j.WaitForNextRun(ctx); err != nil || atomic.LoadInt64(&runs) != before+1 {
+		t.Errorf("expected Trigger to run the job after Stop, got %v", err)
+	}
+}
+
+// TestSchedulerSharedTimer tests the shared timer while jobs are added and removed concurrently.
+func TestSchedulerSharedTimer(t *testing.T) {
+	var runs int64
+	count := func(ctx context.Context) { atomic.AddInt64(&runs, 1) }
+	s := NewScheduler().WithSharedTimer()
+	kept := s.Add(fastJob(10 * time.Millisecond).Execute(count))
+	s.Start()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 10; n++ {
+				s.Remove(s.Add(fastJob(time.Millisecond).Execute(count)))
+			}
+		}()
+	}
+	wg.Wait()
+
+	before := atomic.LoadInt64(&runs)
+	time.Sleep(50 * time.Millisecond)
+	if atomic.LoadInt64(&runs) <= before {
+		t.Errorf("expected the remaining job to keep running on the shared timer")
+	}
+	if ids := s.IDs(); len(ids) != 1 || ids[0] != kept {
+		t.Errorf("expected only job %d to remain, got %v", kept, ids)
+	}
+	s.Stop()
+}
+
+// TestSchedulerSharedTimerFullPool tests that a full pool under LimitQueue drops ticks from the shared timer
+// instead of holding up the other jobs on it.
+func TestSchedulerSharedTimerFullPool(t *testing.T) {
+	release := make(chan struct{})
+	var drops, runs int64
+	stuck := func(ctx context.Context) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+	}
+
+	s := NewScheduler().WithSharedTimer().WithMaxConcurrency(1, LimitQueue)
+	s.Add(fastJob(5 * time.Millisecond).Execute(stuck).OnDrop(func(time.Time) { atomic.AddInt64(&drops, 1) }))
+	s.Add(fastJob(10 * time.Millisecond).SetBlocking(true).Execute(func(ctx context.Context) { atomic.AddInt64(&runs, 1) }))
+	s.Start()
+	time.Sleep(200 * time.Millisecond)
+	close(release)
+	s.Stop()
+
+	if n := atomic.LoadInt64(&runs); n < 10 {
+		t.Errorf("expected the unrelated job to keep running while the pool was full, got %d runs", n)
+	}
+	if atomic.LoadInt64(&drops) == 0 {
+		t.Errorf("expected ticks to be dropped while the pool was full")
+	}
+}
+
+// TestSchedulerSharedTimerHandBack tests that a job parked on the shared timer runs on its own loop once removed.
+func TestSchedulerSharedTimerHandBack(t *testing.T) {
+	leader := Schedule("0 0 1 1 *").Execute(func(ctx context.Context) {})
+	ran := make(chan struct{}, 1)
+	follower := Schedule("* * * * *").AfterJob(leader, 5*time.Millisecond).Execute(func(ctx context.Context) {
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+	})
+
+	s := NewScheduler().WithSharedTimer()
+	id := s.Add(follower)
+	s.Start()
+	s.Stop()
+	s.Remove(id)
+
+	follower.Start()
+	defer follower.Stop()
+	// let the loop park before the job it follows runs
+	time.Sleep(20 * time.Millisecond)
+	leader.Trigger()
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatalf("job removed from the shared timer did not fire on its own loop")
+	}
+}
+
+// TestSchedulerUpcoming tests that Upcoming lists the running jobs due within the window in order.
+func TestSchedulerUpcoming(t *testing.T) {
+	s := NewScheduler()
+	yearly := s.Add(Schedule("0 0 1 1 *").SetName("yearly").Execute(func(ctx context.Context) {}))
+	slow := s.Add(Every(time.Minute).SetName("slow").Execute(func(ctx context.Context) {}))
+	fast := s.Add(Every(30 * time.Second).SetName("fast").Execute(func(ctx context.Context) {}))
+	if runs := s.Upcoming(time.Hour); len(runs) != 0 {
+		t.Errorf("expected nothing upcoming before Start, got %v", runs)
+	}
+
+	s.Start()
+	defer s.Stop()
+	deadline := time.Now().Add(time.Second)
+	for _, id := range []int{yearly, slow, fast} {
+		j, _ := s.Job(id)
+		for _, ok := j.ArmedFireTime(); !ok && time.Now().Before(deadline); _, ok = j.ArmedFireTime() {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	runs := s.Upcoming(10 * time.Minute)
+	if len(runs) != 2 || runs[0].ID != fast || runs[1].ID != slow {
+		t.Fatalf("expected fast then slow, got %v", runs)
+	}
+	if runs[0].Name != "fast" || runs[0].At.After(time.Now().Add(30*time.Second)) {
+		t.Errorf("unexpected entry for the fast job: %+v", runs[0])
+	}
+}
+
+// TestSchedulerStatusHandler tests that the status endpoint reports every job without stopping any.
+func TestSchedulerStatusHandler(t *testing.T) {
+	s := NewScheduler()
+	ran := make(chan struct{}, 1)
+	s.Add(Schedule("0 0 1 1 *").SetName("yearly").Execute(func(ctx context.Context) {}))
+	s.Add(fastJob(5 * time.Millisecond).SetName("fast").Execute(func(ctx context.Context) {
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+	}))
+	s.Start()
+	defer s.Stop()
+	<-ran
+
+	recorder := httptest.NewRecorder()
+	s.StatusHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/cron/status", nil))
+	if ct := recorder.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected a JSON content type, got %q", ct)
+	}
+	var statuses []JobStatus
+	if err := json.Unmarshal(recorder.Body.Bytes(), &statuses); err != nil {
+		t.Fatalf("the handler served invalid JSON: %v", err)
+	}
+	if len(statuses) != 2 || statuses[0].Name != "yearly" || statuses[1].Name != "fast" {
+		t.Fatalf("expected both jobs in id order, got %+v", statuses)
+	}
+	yearly, fast := statuses[0], statuses[1]
+	if !yearly.Running || yearly.LastRun != nil || yearly.NextRun == nil || yearly.Schedule != "0 0 1 1 *" || yearly.Timezone != "UTC" {
+		t.Errorf("unexpected status for the yearly job: %+v", yearly)
+	}
+	if !fast.Running || fast.LastRun == nil || fast.RunCount == 0 {
+		t.Errorf("expected the fast job to report its runs, got %+v", fast)
+	}
+	for _, id := range s.IDs() {
+		if j, _ := s.Job(id); !j.IsRunning() {
+			t.Errorf("serving the status stopped job %d", id)
+		}
+	}
+}
+
+// TestSchedulerReconcile tests that Reconcile adds, removes and restarts jobs by name, leaving unchanged ones running.
+func TestSchedulerReconcile(t *testing.T) {
+	noop := func(ctx context.Context) {}
+	s := NewScheduler()
+	keepID := s.Add(Schedule("0 9 * * *").SetName("keep").Execute(noop))
+	changeID := s.Add(Schedule("0 9 * * *").SetName("change").Execute(noop))
+	s.Add(Schedule("0 9 * * *").SetName("drop").Execute(noop))
+	s.Start()
+	defer s.Stop()
+	keep, _ := s.Job(keepID)
+	change, _ := s.Job(changeID)
+
+	changed := Schedule("0 10 * * *").SetName("change").Execute(noop)
+	desired := []*Job{
+		Schedule("0  9 * * *").SetName("keep").Execute(noop),
+		changed,
+		Schedule("@hourly").SetName("new").Execute(noop),
+		Schedule("@daily").Execute(noop),
+	}
+	result := s.Reconcile(desired)
+	want := ReconcileResult{Added: []string{"new"}, Removed: []string{"drop"}, Restarted: []string{"change"}, Unchanged: []string{"keep"}}
+	if fmt.Sprint(result) != fmt.Sprint(want) {
+		t.Errorf("Reconcile returned %+v, want %+v", result, want)
+	}
+	// reconciling the same set again changes nothing, the nameless job included
+	result = s.Reconcile(desired)
+	want = ReconcileResult{Unchanged: []string{"keep", "change", "new"}}
+	if fmt.Sprint(result) != fmt.Sprint(want) {
+		t.Errorf("reconciling again returned %+v, want %+v", result, want)
+	}
+
+	if j, _ := s.Job(keepID); j != keep || !keep.IsRunning() {
+		t.Errorf("expected the unchanged job to be left running")
+	}
+	if j, _ := s.Job(changeID); j != changed || !changed.IsRunning() || change.IsRunning() {
+		t.Errorf("expected the changed job to be replaced under its id and restarted")
+	}
+	if len(s.IDs()) != 3 {
+		t.Errorf("expected 3 jobs after reconciling, got %d", len(s.IDs()))
+	}
+	for _, id := range s.IDs() {
+		if j, _ := s.Job(id); j.Name == "drop" {
+			t.Errorf("expected the dropped job to be removed")
+		} else if !j.IsRunning() {
+			t.Errorf("expected job %q to be running", j.Name)
+		}
+	}
+}
+
+// TestSchedulerSaveLoadState tests that jobs and their runtime state survive a SaveState and LoadState round-trip.
+func TestSchedulerSaveLoadState(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	lastRun := time.Date(2024, 3, 13, 9, 0, 0, 0, time.UTC)
+	s := NewScheduler()
+	s.Add(Schedule("0 9 * * 1-5").SetName("report").SetTimezone(ny).SetBlocking(true).Execute(func(ctx context.Context) {}).
+		RestoreState(JobState{LastRun: lastRun, RunCount: 7}))
+	s.Add(Every(90 * time.Second).SetName("poll").Execute(func(ctx context.Context) {}))
+
+	var buf bytes.Buffer
+	if err := s.SaveState(&buf); err != nil {
+		t.Fatalf("SaveState failed: %v", err)
+	}
+	var rebound int64
+	loaded, err := LoadState(&buf, map[string]func(context.Context){
+		"report": func(ctx context.Context) { atomic.AddInt64(&rebound, 1) },
+	})
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+
+	ids := loaded.IDs()
+	if len(ids) != 2 {
+		t.Fatalf("expected 2 jobs, got %d", len(ids))
+	}
+	report, _ := loaded.Job(ids[0])
+	poll, _ := loaded.Job(ids[1])
+	if report.Name != "report" || report.scheduleStr != "0 9 * * 1-5" || report.Timezone.String() != "America/New_York" {
+		t.Errorf("unexpected restored job: %v", report)
+	}
+	if state := report.State(); !state.LastRun.Equal(lastRun) || state.RunCount != 7 {
+		t.Errorf("expected the runtime state to be restored, got %+v", state)
+	}
+	report.Trigger()
+	if atomic.LoadInt64(&rebound) != 1 {
+		t.Errorf("expected the function to be re-bound by name")
+	}
+	if poll.Name != "poll" || poll.Fn != nil || !poll.SameSchedule(Every(90*time.Second)) {
+		t.Errorf("expected the unbound job to be restored without a function, got %v", poll)
+	}
+
+	if _, err := LoadState(strings.NewReader("{"), nil); err == nil {
+		t.Errorf("LoadState accepted invalid JSON")
+	}
+}
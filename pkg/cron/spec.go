@@ -0,0 +1,96 @@
+package cron
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ScheduleSpec is a structured, field-by-field description of a Job.
+// It is meant for message types such as protobuf or gRPC requests, where passing
+// a single cron string is less convenient than passing its fields.
+type ScheduleSpec struct {
+	// Second is optional; when empty the schedule uses the 5-field format.
+	Second string `json:"second,omitempty"`
+	// The remaining fields default to "*" when empty.
+	Minute     string `json:"minute,omitempty"`
+	Hour       string `json:"hour,omitempty"`
+	DayOfMonth string `json:"day_of_month,omitempty"`
+	Month      string `json:"month,omitempty"`
+	DayOfWeek  string `json:"day_of_week,omitempty"`
+	// Timezone is an IANA location name such as "America/New_York". Empty means the default timezone,
+	// UTC unless SetDefaultTimezone says otherwise.
+	Timezone string `json:"timezone,omitempty"`
+	Blocking bool   `json:"blocking,omitempty"`
+}
+
+// String returns the cron expression described by the spec.
+func (s ScheduleSpec) String() string {
+	fields := []string{s.Minute, s.Hour, s.DayOfMonth, s.Month, s.DayOfWeek}
+	for i, field := range fields {
+		if field == "" {
+			fields[i] = "*"
+		}
+	}
+	if s.Second != "" {
+		fields = append([]string{s.Second}, fields...)
+	}
+	return strings.Join(fields, " ")
+}
+
+// FromSpec builds a Job from a ScheduleSpec.
+// Unlike Schedule, it returns an error instead of panicking when the spec is invalid,
+// including when a field contains whitespace, which would shift the fields after it.
+func FromSpec(s ScheduleSpec) (*Job, error) {
+	scheduleStr := s.String()
+	fields := []struct{ name, value string }{
+		{"second", s.Second}, {"minute", s.Minute}, {"hour", s.Hour},
+		{"day of month", s.DayOfMonth}, {"month", s.Month}, {"day of week", s.DayOfWeek},
+	}
+	for _, f := range fields {
+		if strings.IndexFunc(f.value, unicode.IsSpace) >= 0 {
+			return nil, &ScheduleError{Schedule: scheduleStr, Err: fmt.Errorf("%s field %q contains whitespace", f.name, f.value)}
+		}
+	}
+	schedule, err := parseSchedule(scheduleStr)
+	if err != nil {
+		return nil, err
+	}
+
+	j := newJob(scheduleStr, schedule)
+	if s.Timezone != "" {
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("cron: invalid timezone %q: %w", s.Timezone, err)
+		}
+		j.Timezone = loc
+	}
+	j.Blocking = s.Blocking
+	return j, nil
+}
+
+// ToSpec describes the Job as a ScheduleSpec.
+// It returns an error if the Job's schedule wasn't built from a 5 or 6 field cron expression.
+// The timezone of an expression such as "TZ=Europe/Berlin 0 9 * * *" becomes the spec's Timezone.
+func (j *Job) ToSpec() (ScheduleSpec, error) {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+
+	fields := strings.Fields(j.scheduleStr)
+	spec := ScheduleSpec{Timezone: j.Timezone.String(), Blocking: j.Blocking}
+	if len(fields) > 0 && isZone(fields[0]) {
+		spec.Timezone = fields[0][strings.Index(fields[0], "=")+1:]
+		fields = fields[1:]
+	}
+	switch len(fields) {
+	case 6:
+		spec.Second = fields[0]
+		fields = fields[1:]
+	case 5:
+	default:
+		return ScheduleSpec{}, fmt.Errorf("cron: schedule %q can't be described by fields", j.scheduleStr)
+	}
+	spec.Minute, spec.Hour, spec.DayOfMonth, spec.Month, spec.DayOfWeek = fields[0], fields[1], fields[2], fields[3], fields[4]
+	return spec, nil
+}
@@ -0,0 +1,74 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestFromSpec tests building a Job from a ScheduleSpec and describing it back.
+func TestFromSpec(t *testing.T) {
+	spec := ScheduleSpec{Second: "*/5", Hour: "9", DayOfWeek: "1-5", Timezone: "America/New_York", Blocking: true}
+
+	job, err := FromSpec(spec)
+	if err != nil {
+		t.Fatalf("FromSpec returned an error: %v", err)
+	}
+	if job.scheduleStr != "*/5 * 9 * * 1-5" {
+		t.Errorf("FromSpec built schedule %q", job.scheduleStr)
+	}
+	if job.Timezone.String() != "America/New_York" || !job.Blocking {
+		t.Errorf("FromSpec did not apply the timezone and blocking settings")
+	}
+
+	got, err := job.ToSpec()
+	if err != nil {
+		t.Fatalf("ToSpec returned an error: %v", err)
+	}
+	want := ScheduleSpec{Second: "*/5", Minute: "*", Hour: "9", DayOfMonth: "*", Month: "*", DayOfWeek: "1-5", Timezone: "America/New_York", Blocking: true}
+	if got != want {
+		t.Errorf("ToSpec returned %+v, want %+v", got, want)
+	}
+}
+
+// TestToSpecTimezonePrefix tests that the timezone of a prefixed expression goes into the spec's Timezone.
+func TestToSpecTimezonePrefix(t *testing.T) {
+	got, err := Schedule("TZ=Europe/Berlin 0 9 * * *").ToSpec()
+	if err != nil {
+		t.Fatalf("ToSpec returned an error: %v", err)
+	}
+	want := ScheduleSpec{Minute: "0", Hour: "9", DayOfMonth: "*", Month: "*", DayOfWeek: "*", Timezone: "Europe/Berlin"}
+	if got != want {
+		t.Errorf("ToSpec returned %+v, want %+v", got, want)
+	}
+}
+
+// TestFromSpecInvalid tests that FromSpec reports bad fields and timezones as errors.
+func TestFromSpecInvalid(t *testing.T) {
+	if _, err := FromSpec(ScheduleSpec{Minute: "61"}); err == nil {
+		t.Errorf("FromSpec accepted an out of range minute")
+	}
+	if _, err := FromSpec(ScheduleSpec{Timezone: "Nowhere/Special"}); err == nil {
+		t.Errorf("FromSpec accepted an unknown timezone")
+	}
+	if _, err := FromSpec(ScheduleSpec{Minute: "0 9"}); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("FromSpec accepted a field with whitespace, got %v", err)
+	}
+}
+
+// TestFromSpecDefaultTimezone tests that a spec without a timezone takes the default one.
+func TestFromSpecDefaultTimezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	SetDefaultTimezone(tokyo)
+	defer SetDefaultTimezone(nil)
+	job, err := FromSpec(ScheduleSpec{Hour: "9"})
+	if err != nil {
+		t.Fatalf("FromSpec returned an error: %v", err)
+	}
+	if job.Timezone != tokyo {
+		t.Errorf("expected the default timezone, got %v", job.Timezone)
+	}
+}
@@ -0,0 +1,68 @@
+package cron
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// JobStatus is a point-in-time view of one of a Scheduler's jobs, see Scheduler.Status.
+// LastRun and NextRun are nil when the job never ran or never fires again.
+type JobStatus struct {
+	ID       int        `json:"id"`
+	Name     string     `json:"name,omitempty"`
+	Schedule string     `json:"schedule"`
+	Timezone string     `json:"timezone"`
+	Running  bool       `json:"running"`
+	LastRun  *time.Time `json:"last_run,omitempty"`
+	NextRun  *time.Time `json:"next_run,omitempty"`
+	RunCount uint64     `json:"run_count"`
+}
+
+// Status returns the status of every registered job in ascending id order.
+// It only reads each job's state, so running jobs are not disturbed.
+func (s *Scheduler) Status() []JobStatus {
+	s.mutex.Lock()
+	statuses := make([]JobStatus, 0, len(s.jobs))
+	jobs := make(map[int]*Job, len(s.jobs))
+	for id, j := range s.jobs {
+		jobs[id] = j
+	}
+	s.mutex.Unlock()
+
+	for id, j := range jobs {
+		next := j.NextRun()
+		j.mutex.RLock()
+		status := JobStatus{
+			ID:       id,
+			Name:     j.Name,
+			Schedule: j.scheduleStr,
+			Timezone: j.Timezone.String(),
+			Running:  j.isRunning,
+			RunCount: j.runCount.Load(),
+		}
+		if !j.lastRun.IsZero() {
+			lastRun := j.lastRun
+			status.LastRun = &lastRun
+		}
+		j.mutex.RUnlock()
+		if !next.IsZero() {
+			status.NextRun = &next
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(a, b int) bool { return statuses[a].ID < statuses[b].ID })
+	return statuses
+}
+
+// StatusHandler returns an http.Handler serving Status as a JSON array, ready to be mounted at an
+// operations endpoint such as /cron/status.
+func (s *Scheduler) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(s.Status()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
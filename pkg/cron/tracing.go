@@ -0,0 +1,45 @@
+package cron
+
+import "context"
+
+// Tracer starts a span around each run of a Job, see WithTracer. It is deliberately smaller than an
+// OpenTelemetry trace.Tracer so this package doesn't depend on OpenTelemetry; an adapter calling
+// tracer.Start(ctx, name) and wrapping the returned trace.Span takes a few lines.
+type Tracer interface {
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	// RecordError records an error returned by the run, or a panic recovered from it.
+	RecordError(err error)
+	// End finishes the span once the run has returned.
+	End()
+}
+
+// WithTracer wraps every run of the Job in a span started by tracer and named after the Job, or after its
+// schedule string if it has no Name. The function receives the span's context, so its own calls can be
+// correlated with the run, and errors from a function set with ExecuteE are recorded on the span.
+// Retries from WithRetry happen within the same span. Passing nil stops the tracing.
+func (j *Job) WithTracer(tracer Tracer) *Job {
+	// locking in case you change on the fly but would not recommend
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.tracer = tracer
+	return j
+}
+
+// startSpan starts a span for a run with tracer, if there is one, and returns the context to run with
+// and a function ending the span with the run's error.
+func startSpan(ctx context.Context, tracer Tracer, name string) (context.Context, func(err error)) {
+	if tracer == nil {
+		return ctx, func(error) {}
+	}
+	ctx, span := tracer.Start(ctx, name)
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+	}
+}